@@ -20,8 +20,24 @@ type Config struct {
 	Token     string `json:"token"`
 	EditorCmd string `json:"editor"`
 	// TODO interface type switch, strconv.Atoi if not an int.
-	NotificationTimeout int    `json:"notification_timeout"`
-	TimeFormat          string `json:"time_format"`
+	NotificationTimeout int           `json:"notification_timeout"`
+	TimeFormat          string        `json:"time_format"`
+	Notifications       Notifications `json:"notifications"`
+	// HighlightWords count as mentions and are emphasized.
+	HighlightWords []string `json:"highlight_words"`
+}
+
+// Notifications contains the notification policy.
+//
+// Modes are one of all, mentions, ims, none or mute.
+type Notifications struct {
+	// Default applies to rooms without a mode of their own, leave it
+	// empty to use your global slack preference.
+	Default string `json:"default"`
+	// Channels maps #channel, @user or &mpim names to a mode.
+	Channels map[string]string `json:"channels"`
+	// Slack also takes your slack notification preferences into account.
+	Slack bool `json:"slack"`
 }
 
 func createConfig(path string) error {
@@ -38,7 +54,13 @@ func createConfig(path string) error {
     "token":    "-",
     "editor":   "",
 	"notification_timeout": 2500,
-	"time_format": "Jan 02 15:04:05"
+	"time_format": "Jan 02 15:04:05",
+	"notifications": {
+		"default": "",
+		"channels": {},
+		"slack": true
+	},
+	"highlight_words": []
 }`)
 
 	return err
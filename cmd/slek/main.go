@@ -2,11 +2,13 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/user"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -19,24 +21,41 @@ import (
 )
 
 var (
-	stderr = log.New(os.Stderr, "", 0)
-	help   = slk.ListItems{
-		{slk.ListItemStatusTitle, "HELP (#room = @user #group or #channel)"},
+	stderr     = log.New(os.Stderr, "", 0)
+	reSed      = regexp.MustCompile(`^s/([^/]+)/([^/]*)/$`)
+	reReaction = regexp.MustCompile(`^([+-]):([^:\s]+):$`)
+	reMention  = regexp.MustCompile(`(^|\s)@([a-z0-9._-]+)`)
+	reEmoji    = regexp.MustCompile(`^:[^:\s]+:$`)
+	help       = slk.ListItems{
+		{slk.ListItemStatusTitle, "HELP (#room = @user &mpim #group or #channel)"},
 
 		{slk.ListItemStatusTitle, "General"},
 		{slk.ListItemStatusNone, "quit : quit slek"},
 		{slk.ListItemStatusNone, "exit : quit slek"},
 		{slk.ListItemStatusNone, "about: about slek"},
 		{slk.ListItemStatusNone, "clear: clear the chat screen"},
+		{slk.ListItemStatusNone, "mpim <user> <user>...: open a multi-party im"},
 		{slk.ListItemStatusNone, ""},
 
 		{slk.ListItemStatusTitle, "Presence"},
 		{slk.ListItemStatusNone, "active: set yourself to active"},
 		{slk.ListItemStatusNone, "away  : set yourself to away"},
+		{slk.ListItemStatusNone, "dnd <minutes>: do not disturb for <minutes>"},
+		{slk.ListItemStatusNone, "dnd off      : end do not disturb"},
+		{slk.ListItemStatusNone, "status [:emoji:] <text> [duration]: set your status, e.g.: status :palm_tree: on vacation 48h"},
+		{slk.ListItemStatusNone, "status off                        : clear your status"},
 		{slk.ListItemStatusNone, ""},
 
 		{slk.ListItemStatusTitle, "Messages"},
-		{slk.ListItemStatusNone, "#room <msg>: send <msg>"},
+		{slk.ListItemStatusNone, "#room <msg>            : send <msg>"},
+		{slk.ListItemStatusNone, "#room /edit <msg>      : replace your last message with <msg>"},
+		{slk.ListItemStatusNone, "#room s/<old>/<new>/   : replace <old> with <new> in your last message"},
+		{slk.ListItemStatusNone, "#room /delete          : delete your last message"},
+		{slk.ListItemStatusNone, "#room /thread <n> <msg>: reply in the thread of the <n>th most recent message"},
+		{slk.ListItemStatusNone, "#room /thread <n>      : show and follow the thread of the <n>th most recent message"},
+		{slk.ListItemStatusNone, "#room +:emoji: [n]     : react to the latest (or <n>th most recent) message"},
+		{slk.ListItemStatusNone, "#room -:emoji: [n]     : remove your reaction"},
+		{slk.ListItemStatusNone, "@name in a message     : mention a user or user group, completed before sending"},
 		{slk.ListItemStatusNone, ""},
 
 		{slk.ListItemStatusTitle, "Rooms"},
@@ -44,16 +63,46 @@ var (
 		{slk.ListItemStatusNone, "#room /u  | /users:   : list online users in #room"},
 		{slk.ListItemStatusNone, "#room /au | /all-users: list all users in #room"},
 		{slk.ListItemStatusNone, "#room /p  | /pins     : list pins of #room"},
+		{slk.ListItemStatusNone, "#room /pin [n]        : pin the latest (or <n>th most recent) message"},
+		{slk.ListItemStatusNone, "#room /unpin [n]      : unpin the latest (or <n>th most recent) message"},
+		{slk.ListItemStatusNone, "#room /star [n]       : star the latest (or <n>th most recent) message"},
+		{slk.ListItemStatusNone, "#room /unstar [n]     : unstar the latest (or <n>th most recent) message"},
+		{slk.ListItemStatusNone, "#room /leave          : leave #room or close &mpim"},
+		{slk.ListItemStatusNone, "create #name [private]: create a channel or private group"},
+		{slk.ListItemStatusNone, "#room /rename <name>  : rename #room"},
+		{slk.ListItemStatusNone, "#room /archive        : archive #room"},
+		{slk.ListItemStatusNone, "#room /unarchive      : unarchive #room"},
+		{slk.ListItemStatusNone, "#room /kick @user     : remove @user from #room"},
+		{slk.ListItemStatusNone, "#room /topic <text>   : set the topic of #room"},
+		{slk.ListItemStatusNone, "#room /purpose <text> : set the purpose of #room"},
 		{slk.ListItemStatusNone, "#room /f  | /files    : list files of #room"},
 		{slk.ListItemStatusNone, "#room !path <comment> : upload file to #room"},
 		{slk.ListItemStatusNone, ""},
 
+		{slk.ListItemStatusTitle, "Reminders (<when> = 10m, 2h, 15:04, 2006-01-02 or 2006-01-02T15:04)"},
+		{slk.ListItemStatusNone, "remind <when> <text>       : remind yourself"},
+		{slk.ListItemStatusNone, "remind @user <when> <text> : remind @user"},
+		{slk.ListItemStatusNone, "reminders                  : list your reminders"},
+		{slk.ListItemStatusNone, "reminder done <n>          : mark the <n>th listed reminder as done"},
+		{slk.ListItemStatusNone, "reminder delete <n>        : delete the <n>th listed reminder"},
+		{slk.ListItemStatusNone, ""},
+
+		{slk.ListItemStatusTitle, "Search"},
+		{slk.ListItemStatusNone, "search <query>        : search messages"},
+		{slk.ListItemStatusNone, "#room /search <query> : search messages in #room"},
+		{slk.ListItemStatusNone, "search-more | sm      : next page of search results"},
+		{slk.ListItemStatusNone, "goto <n>              : go to the <n>th search result"},
+		{slk.ListItemStatusNone, ""},
+
 		{slk.ListItemStatusTitle, "Listings"},
 		{slk.ListItemStatusNone, "unread       | ur: list rooms with unread messages"},
 		{slk.ListItemStatusNone, "users        | u : list online users"},
 		{slk.ListItemStatusNone, "all-users    | au: list all users"},
 		{slk.ListItemStatusNone, "channels     | c : list joined channels"},
 		{slk.ListItemStatusNone, "all-channels | ac: list all channels"},
+		{slk.ListItemStatusNone, "mpims        | m : list open multi-party ims"},
+		{slk.ListItemStatusNone, "all-mpims    | am: list all multi-party ims"},
+		{slk.ListItemStatusNone, "stars            : list your starred items"},
 		{slk.ListItemStatusNone, ""},
 
 		{slk.ListItemStatusTitle, "Keybinds"},
@@ -126,6 +175,7 @@ func newSlek(token, tFormat, editorCmd string, ntfy time.Duration) *slek {
 func (s *slek) normalCommand(cmd string, args []string) bool {
 	switch cmd {
 	case "?", "h", "help", "/help":
+		s.c.CloseThread()
 		s.t.List(help, false)
 	case "about":
 		about, err := assets.Asset("about")
@@ -134,6 +184,7 @@ func (s *slek) normalCommand(cmd string, args []string) bool {
 			return true
 		}
 
+		s.c.CloseThread()
 		s.t.Meta(string(about))
 		return true
 	case "quit", "exit":
@@ -154,21 +205,281 @@ func (s *slek) normalCommand(cmd string, args []string) bool {
 	case "all-users", "au":
 		s.c.List(slk.TypeUser, false)
 		return true
+	case "mpims", "m":
+		s.c.List(slk.TypeMPIM, true)
+		return true
+	case "all-mpims", "am":
+		s.c.List(slk.TypeMPIM, false)
+		return true
+	case "mpim":
+		s.openMPIM(args)
+		return true
 
 	case "unread", "ur":
 		s.c.ListUnread()
 		return true
 
+	case "stars":
+		s.c.Stars()
+		return true
+
+	case "search":
+		if len(args) == 0 {
+			s.t.Warn("Usage: search <query>")
+			return true
+		}
+
+		s.c.Search(trimFields(args))
+		return true
+	case "search-more", "sm":
+		s.c.SearchMore()
+		return true
+	case "goto":
+		var n int
+		if len(args) != 0 {
+			n, _ = strconv.Atoi(args[0])
+		}
+
+		if n < 1 {
+			s.t.Warn("Usage: goto <n>")
+			return true
+		}
+
+		s.c.GotoHit(n)
+		return true
+
 	case "active":
 		s.c.SetPresence(slk.UserPresenceActive)
 		return true
 	case "away":
 		s.c.SetPresence(slk.UserPresenceAway)
 		return true
+	case "create":
+		if len(args) == 0 || len(args) > 2 ||
+			len(args) == 2 && args[1] != "private" {
+			s.t.Warn("Usage: create #name [private]")
+			return true
+		}
+
+		name := strings.TrimPrefix(args[0], "#")
+		e, err := s.c.Create(name, len(args) == 2)
+		if err != nil {
+			return true
+		}
+
+		s.c.Switch(e)
+		s.t.SetInput(e.QualifiedName()+" ", -1, -1, false)
+		return true
+	case "remind":
+		var u slk.Entity
+		if len(args) != 0 && strings.HasPrefix(args[0], "@") {
+			if u = s.user(args[0][1:]); u == nil {
+				return true
+			}
+
+			args = args[1:]
+		}
+
+		if len(args) < 2 {
+			s.t.Warn("Usage: remind [@user] <when> <text>")
+			return true
+		}
+
+		s.c.Remind(u, args[0], trimFields(args[1:]))
+		return true
+	case "reminders":
+		s.c.Reminders()
+		return true
+	case "reminder":
+		var n int
+		if len(args) == 2 {
+			n, _ = strconv.Atoi(args[1])
+		}
+
+		switch {
+		case n < 1:
+		case args[0] == "done":
+			s.c.CompleteReminder(n)
+			return true
+		case args[0] == "delete":
+			s.c.DeleteReminder(n)
+			return true
+		}
+
+		s.t.Warn("Usage: reminder done|delete <n>")
+		return true
+	case "status":
+		s.status(args)
+		return true
+	case "dnd":
+		if len(args) == 1 && args[0] == "off" {
+			s.c.EndDND()
+			return true
+		}
+
+		var n int
+		if len(args) == 1 {
+			n, _ = strconv.Atoi(args[0])
+		}
+
+		if n < 1 {
+			s.t.Warn("Usage: dnd <minutes> | dnd off")
+			return true
+		}
+
+		s.c.Snooze(n)
+		return true
 	}
 	return false
 }
 
+// status sets or clears (no args or 'off') our custom status.
+func (s *slek) status(args []string) {
+	if len(args) == 0 || len(args) == 1 && args[0] == "off" {
+		s.c.SetStatus("", "", 0)
+		return
+	}
+
+	var emoji string
+	if reEmoji.MatchString(args[0]) {
+		emoji = args[0]
+		args = args[1:]
+	}
+
+	var d time.Duration
+	if len(args) != 0 {
+		last, err := time.ParseDuration(args[len(args)-1])
+		if err == nil && last > 0 {
+			d = last
+			args = args[:len(args)-1]
+		}
+	}
+
+	s.c.SetStatus(emoji, trimFields(args), d)
+}
+
+// openMPIM opens and switches to a multi-party im with the given users.
+func (s *slek) openMPIM(names []string) {
+	if len(names) < 2 {
+		s.t.Warn("Usage: mpim <user> <user>...")
+		return
+	}
+
+	users := make([]slk.Entity, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimPrefix(name, "@")
+		u := s.user(name)
+		if u == nil {
+			return
+		}
+
+		users = append(users, u)
+	}
+
+	e, err := s.c.OpenMPIM(users...)
+	if err != nil {
+		return
+	}
+
+	s.c.Switch(e)
+	s.t.SetInput(e.QualifiedName()+" ", -1, -1, false)
+}
+
+// user returns the user named (or uniquely fuzzy matched by) name.
+func (s *slek) user(name string) slk.Entity {
+	opts := s.c.Fuzzy(slk.TypeUser, name)
+	for i := range opts {
+		if opts[i].Name() == name {
+			return opts[i]
+		}
+	}
+
+	switch len(opts) {
+	case 0:
+		s.t.Notice(fmt.Sprintf("No such user '%s'", name))
+		return nil
+	case 1:
+		return opts[0]
+	}
+
+	names := make([]string, 0, len(opts))
+	for i := range opts {
+		names = append(names, opts[i].Name())
+	}
+
+	s.t.Notice(
+		fmt.Sprintf(
+			"Did you mean any of: %s?",
+			strings.Join(names, ", "),
+		),
+	)
+
+	return nil
+}
+
+// completeMentions completes @mentions in msg that are not the exact name
+// of a user or user group but match a single one, slack won't link those.
+// Returns false and notifies the user if msg should not be sent as is.
+func (s *slek) completeMentions(msg string) (string, bool) {
+	ok := true
+	msg = reMention.ReplaceAllStringFunc(
+		msg,
+		func(str string) string {
+			m := reMention.FindStringSubmatch(str)
+			switch m[2] {
+			case "here", "channel", "everyone":
+				return str
+			}
+
+			opts := s.c.FuzzyMention(m[2])
+			for i := range opts {
+				if opts[i] == m[2] {
+					return str
+				}
+			}
+
+			switch len(opts) {
+			case 0:
+				s.t.Notice(fmt.Sprintf("@%s is not a user or user group", m[2]))
+				return str
+			case 1:
+				ok = false
+				s.t.Notice(fmt.Sprintf("Completed @%s to @%s", m[2], opts[0]))
+				return m[1] + "@" + opts[0]
+			}
+
+			ok = false
+			s.t.Notice(
+				fmt.Sprintf(
+					"@%s is not a user or user group, did you mean any of: %s?",
+					m[2],
+					strings.Join(opts, ", "),
+				),
+			)
+
+			return str
+		},
+	)
+
+	return msg, ok
+}
+
+// recent returns the timestamp of the n-th most recent message in e,
+// n is read from args and defaults to 1.
+func (s *slek) recent(e slk.Entity, args []string) (string, bool) {
+	n := 1
+	if len(args) != 0 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+			s.t.Warn(fmt.Sprintf("Invalid message index '%s'", args[0]))
+			return "", false
+		}
+	}
+
+	ts, err := s.c.Recent(e, n)
+	return ts, err == nil
+}
+
 func (s *slek) entityCommand(e slk.Entity, args []string) bool {
 	if len(args) == 0 {
 		return true
@@ -206,7 +517,97 @@ func (s *slek) entityCommand(e slk.Entity, args []string) bool {
 		return true
 	}
 
+	if m := reSed.FindStringSubmatch(trimFields(args)); m != nil {
+		s.c.ReplaceLast(e, m[1], m[2])
+		return true
+	}
+
+	if m := reReaction.FindStringSubmatch(args[0]); m != nil && len(args) < 3 {
+		n := 1
+		if len(args) == 2 {
+			var err error
+			if n, err = strconv.Atoi(args[1]); err != nil {
+				// Just a message.
+				return false
+			}
+		}
+
+		ts, err := s.c.Recent(e, n)
+		if err != nil {
+			return true
+		}
+
+		if m[1] == "+" {
+			s.c.AddReaction(e, ts, m[2])
+			return true
+		}
+
+		s.c.RemoveReaction(e, ts, m[2])
+		return true
+	}
+
 	switch args[0] {
+	case "/edit":
+		if len(args) < 2 {
+			s.t.Warn("Usage: #room /edit <msg>")
+			return true
+		}
+
+		s.c.EditLast(e, trimFields(args[1:]))
+		return true
+	case "/delete":
+		s.c.DeleteLast(e)
+		return true
+	case "/search":
+		if len(args) < 2 {
+			s.t.Warn("Usage: #room /search <query>")
+			return true
+		}
+
+		s.c.SearchIn(e, trimFields(args[1:]))
+		return true
+	case "/thread", "/t":
+		var n int
+		if len(args) > 1 {
+			n, _ = strconv.Atoi(args[1])
+		}
+
+		if n < 1 {
+			s.t.Warn("Usage: #room /thread <n> [msg]")
+			return true
+		}
+
+		ts, err := s.c.Recent(e, n)
+		if err != nil {
+			return true
+		}
+
+		if len(args) < 3 {
+			s.c.Thread(e, ts)
+			return true
+		}
+
+		msg, ok := s.completeMentions(trimFields(args[2:]))
+		if !ok {
+			s.t.SetInput(
+				fmt.Sprintf("%s /thread %d %s", e.QualifiedName(), n, msg),
+				-1,
+				-1,
+				false,
+			)
+			return true
+		}
+
+		if err := s.c.Reply(e, ts, msg); err != nil {
+			s.t.SetInput(
+				fmt.Sprintf("%s /thread %d %s", e.QualifiedName(), n, msg),
+				-1,
+				-1,
+				false,
+			)
+		}
+
+		return true
 	case "/history", "/hist", "/h":
 		var n int
 		if len(args) > 1 {
@@ -222,6 +623,26 @@ func (s *slek) entityCommand(e slk.Entity, args []string) bool {
 	case "/p", "/pins":
 		s.c.Pins(e)
 		return true
+	case "/pin":
+		if ts, ok := s.recent(e, args[1:]); ok {
+			s.c.Pin(e, ts)
+		}
+		return true
+	case "/unpin":
+		if ts, ok := s.recent(e, args[1:]); ok {
+			s.c.Unpin(e, ts)
+		}
+		return true
+	case "/star":
+		if ts, ok := s.recent(e, args[1:]); ok {
+			s.c.Star(e, ts)
+		}
+		return true
+	case "/unstar":
+		if ts, ok := s.recent(e, args[1:]); ok {
+			s.c.Unstar(e, ts)
+		}
+		return true
 	case "/f", "/files":
 		s.c.Uploads(e)
 		return true
@@ -230,6 +651,47 @@ func (s *slek) entityCommand(e slk.Entity, args []string) bool {
 		return true
 	case "/au", "/all-users":
 		s.c.Members(e, false)
+		return true
+	case "/topic":
+		if len(args) < 2 {
+			s.t.Warn("Usage: #room /topic <text>")
+			return true
+		}
+
+		s.c.SetTopic(e, trimFields(args[1:]))
+		return true
+	case "/purpose":
+		if len(args) < 2 {
+			s.t.Warn("Usage: #room /purpose <text>")
+			return true
+		}
+
+		s.c.SetPurpose(e, trimFields(args[1:]))
+		return true
+	case "/rename":
+		if len(args) != 2 {
+			s.t.Warn("Usage: #room /rename <name>")
+			return true
+		}
+
+		s.c.Rename(e, strings.TrimPrefix(args[1], "#"))
+		return true
+	case "/archive":
+		s.c.Archive(e)
+		return true
+	case "/unarchive":
+		s.c.Unarchive(e)
+		return true
+	case "/kick":
+		if len(args) != 2 {
+			s.t.Warn("Usage: #room /kick @user")
+			return true
+		}
+
+		if u := s.user(strings.TrimPrefix(args[1], "@")); u != nil {
+			s.c.Kick(e, u)
+		}
+
 		return true
 	case "/join":
 		s.c.Join(e)
@@ -275,6 +737,7 @@ func (s *slek) run() error {
 	types := map[byte]slk.EntityType{
 		'@': slk.TypeUser,
 		'#': slk.TypeChannel,
+		'&': slk.TypeMPIM,
 	}
 
 	s.t.BindKey(gocui.KeyCtrlE, func() error {
@@ -284,7 +747,6 @@ func (s *slek) run() error {
 
 	s.t.BindKey(gocui.KeyCtrlU, func() error {
 		// gocui can't handle key events that spawn > 20 userEvents
-		// TODO test thread safety.
 		go func() {
 			e, err := s.c.NextUnread()
 			if err != nil {
@@ -297,6 +759,34 @@ func (s *slek) run() error {
 		return nil
 	})
 
+	go func() {
+		// The entity of the current input line, only resolved again once
+		// its name changes.
+		var prefix string
+		var e slk.Entity
+		for i := range s.t.Keys() {
+			args := strings.FieldsFunc(i, isSpace)
+			// Only once a message is being typed after the entity.
+			if len(args) < 2 {
+				continue
+			}
+
+			eType := types[args[0][0]]
+			if eType == "" || args[1][0] == '/' {
+				continue
+			}
+
+			if args[0] != prefix {
+				prefix = args[0]
+				e, _ = s.c.Lookup(eType, prefix[1:])
+			}
+
+			if e != nil {
+				s.c.Typing(e)
+			}
+		}
+	}()
+
 	go func() {
 		for i := range s.input {
 			args := strings.FieldsFunc(strings.TrimSpace(string(i)), isSpace)
@@ -345,7 +835,17 @@ func (s *slek) run() error {
 				continue
 			}
 
-			msg := trimFields(args)
+			msg, ok := s.completeMentions(trimFields(args))
+			if !ok {
+				s.t.SetInput(
+					fmt.Sprintf("%s %s", e.QualifiedName(), msg),
+					-1,
+					-1,
+					false,
+				)
+				continue
+			}
+
 			if err := s.c.Post(e, msg); err != nil {
 				s.t.SetInput(msg, -1, -1, false)
 			}
@@ -367,6 +867,26 @@ func (s *slek) run() error {
 	}
 }
 
+func notifyPolicy(conf config.Notifications) (slk.NotifyPolicy, error) {
+	policy := slk.NotifyPolicy{
+		Entities:   make(map[string]slk.NotifyMode, len(conf.Channels)),
+		SlackPrefs: conf.Slack,
+	}
+
+	var err error
+	if policy.Default, err = slk.ParseNotifyMode(conf.Default); err != nil {
+		return policy, err
+	}
+
+	for name, mode := range conf.Channels {
+		if policy.Entities[name], err = slk.ParseNotifyMode(mode); err != nil {
+			return policy, fmt.Errorf("%s: %s", name, err)
+		}
+	}
+
+	return policy, nil
+}
+
 func main() {
 	var defaultFile string
 	if u, err := user.Current(); err == nil {
@@ -392,9 +912,16 @@ func main() {
 		conf.TimeFormat = "Jan 02 15:04:05"
 	}
 
-	ntfy := time.Duration(conf.NotificationTimeout * 1e6)
-	err = newSlek(conf.Token, conf.TimeFormat, conf.EditorCmd, ntfy).run()
+	policy, err := notifyPolicy(conf.Notifications)
 	if err != nil {
 		stderr.Fatal(err)
 	}
+
+	ntfy := time.Duration(conf.NotificationTimeout * 1e6)
+	s := newSlek(conf.Token, conf.TimeFormat, conf.EditorCmd, ntfy)
+	s.c.SetNotifyPolicy(policy)
+	s.c.SetHighlightWords(conf.HighlightWords)
+	if err = s.run(); err != nil {
+		stderr.Fatal(err)
+	}
 }
@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/frizinak/slek/slk"
@@ -25,8 +26,9 @@ const (
 	colorBgYellow = "\033[1;30;43m"
 	colorBgGray   = "\033[1;30;47m"
 
-	colorBold   = "\033[1m"
-	colorItalic = "\033[32m"
+	colorBold      = "\033[1m"
+	colorItalic    = "\033[32m"
+	colorHighlight = "\033[1;33m"
 
 	colorReset = "\033[0m"
 )
@@ -35,6 +37,10 @@ var (
 	reInlineCode = regexp.MustCompile("`([^`]+)`")
 	reCode       = regexp.MustCompile("(?s)\n?```(.*?)```\n?")
 
+	// rePlaceholder matches markup placeholders which should not contain
+	// highlight words.
+	rePlaceholder = regexp.MustCompile("\001\\[\\d+\\]")
+
 	markups = []*markup{
 		// _italic_
 		&markup{
@@ -91,6 +97,13 @@ var (
 	}
 )
 
+// highlightPrefix and highlightSuffix temporarily surround highlighted
+// words, see markup.
+const (
+	highlightPrefix = "\001[9]"
+	highlightSuffix = "\001[10]"
+)
+
 type markup struct {
 	re         *regexp.Regexp
 	repl       string
@@ -107,16 +120,31 @@ type msgPrefix struct {
 	channel string
 	from    string
 	ts      time.Time
+	thread  time.Time
 }
 
 type format struct {
+	// mutex guards ownUsername and lastPrefix.
+	mutex       sync.Mutex
 	ownUsername string
 	timeFormat  string
 	lastPrefix  *msgPrefix
+
+	// highlightMutex guards highlight.
+	highlightMutex sync.RWMutex
+	highlight      *regexp.Regexp
 }
 
 func (t *format) setUsername(username string) {
+	t.mutex.Lock()
 	t.ownUsername = username
+	t.mutex.Unlock()
+}
+
+func (t *format) setHighlight(re *regexp.Regexp) {
+	t.highlightMutex.Lock()
+	t.highlight = re
+	t.highlightMutex.Unlock()
 }
 
 func (t *format) wrap(str string, len uint) string {
@@ -138,13 +166,163 @@ func (t *format) Msg(
 	from,
 	msg string,
 	ts time.Time,
+	thread *slk.Thread,
 	section bool,
 ) string {
+	header, body := t.msg(channel, from, msg, ts, thread, section)
+	if header == "" {
+		return body
+	}
+
+	return fmt.Sprintf("%s\n%s", header, body)
+}
+
+// msg formats a message and returns the header (empty if the previous
+// message was sent by the same user shortly before) and body separately.
+func (t *format) msg(
+	channel,
+	from,
+	msg string,
+	ts time.Time,
+	thread *slk.Thread,
+	section bool,
+) (header, body string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
 	colorUser := colorBgBlue
 	if from == t.ownUsername {
 		colorUser = colorBgGray
 	}
 
+	var threadTS time.Time
+	body = t.markup(msg)
+	if thread != nil {
+		threadTS = thread.TS
+		body = t.indent(body)
+	}
+
+	if section ||
+		t.lastPrefix == nil ||
+		t.lastPrefix.channel != channel ||
+		t.lastPrefix.from != from ||
+		!t.lastPrefix.thread.Equal(threadTS) ||
+		ts.Sub(t.lastPrefix.ts) > time.Minute*15 {
+
+		prefix := ""
+		header = fmt.Sprintf(
+			"\n%s %-18s %s %s",
+			colorUser,
+			fmt.Sprintf("%s:", from),
+			colorReset,
+			ts.Format(t.timeFormat),
+		)
+
+		if section || t.lastPrefix == nil || t.lastPrefix.channel != channel {
+			l := runewidth.StringWidth(header) -
+				runewidth.StringWidth(colorUser) -
+				runewidth.StringWidth(colorReset) - 2
+			prefix = fmt.Sprintf(
+				"\n%s %-"+strconv.Itoa(l)+"s%s",
+				colorBgGreen,
+				channel,
+				colorReset,
+			)
+		}
+
+		header = prefix + header
+		if thread != nil {
+			header += "\n" + t.threadTag(thread)
+		}
+	}
+
+	t.lastPrefix = &msgPrefix{channel, from, ts, threadTS}
+	return
+}
+
+// threadTag describes the parent of a thread reply.
+func (t *format) threadTag(thread *slk.Thread) string {
+	if thread.Text == "" {
+		return fmt.Sprintf(
+			"  %s↳ thread %s%s",
+			colorGray,
+			thread.TS.Format(t.timeFormat),
+			colorReset,
+		)
+	}
+
+	text := strings.TrimSpace(strings.Split(thread.Text, "\n")[0])
+	if runewidth.StringWidth(text) > 50 {
+		text = runewidth.Truncate(text, 50, "...")
+	}
+
+	return fmt.Sprintf(
+		"  %s↳ %s: %s%s",
+		colorGray,
+		thread.From,
+		text,
+		colorReset,
+	)
+}
+
+// indent indents all lines of a thread reply.
+func (t *format) indent(body string) string {
+	lines := strings.Split(body, "\n")
+	for i := range lines {
+		lines[i] = "  " + lines[i]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// highlightText surrounds our highlight words in msg with highlightPrefix
+// and highlightSuffix, skipping markup placeholders and everything
+// slk.HighlightIndex skips.
+func (t *format) highlightText(msg string) string {
+	t.highlightMutex.RLock()
+	re := t.highlight
+	t.highlightMutex.RUnlock()
+
+	matches := slk.HighlightIndex(re, msg)
+	if len(matches) == 0 {
+		return msg
+	}
+
+	skip := rePlaceholder.FindAllStringIndex(msg, -1)
+	parts := make([]string, 0, len(matches)*4+1)
+	last := 0
+	for _, m := range matches {
+		if overlaps(skip, m[0], m[1]) {
+			continue
+		}
+
+		parts = append(
+			parts,
+			msg[last:m[0]],
+			highlightPrefix,
+			msg[m[0]:m[1]],
+			highlightSuffix,
+		)
+		last = m[1]
+	}
+
+	parts = append(parts, msg[last:])
+	return strings.Join(parts, "")
+}
+
+// overlaps reports whether start-end overlaps any of the given ranges.
+func overlaps(ranges [][]int, start, end int) bool {
+	for _, r := range ranges {
+		if r[0] < end && start < r[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// markup replaces slack markup in msg with terminal colors.
+func (t *format) markup(msg string) string {
 	// TODO This is filthy, use a proper markdown parser or just
 	// ... better code.
 	// Anyway we replace the regexes with \001[\d]
@@ -167,6 +345,8 @@ func (t *format) Msg(
 		msg = m.re.ReplaceAllString(msg, m.repl)
 	}
 
+	msg = t.highlightText(msg)
+
 	cleanMarkup := func(str string) string {
 		for _, m := range markups {
 			str = strings.Replace(str, m.prefixRepl, m.prefix, -1)
@@ -214,50 +394,75 @@ func (t *format) Msg(
 		msg = strings.Replace(msg, m.suffixRepl, m.colorEnd, -1)
 	}
 
-	msg = strings.Trim(msg, "\n")
-	if section ||
-		t.lastPrefix == nil ||
-		t.lastPrefix.channel != channel ||
-		t.lastPrefix.from != from ||
-		ts.Sub(t.lastPrefix.ts) > time.Minute*15 {
+	msg = strings.Replace(msg, highlightPrefix, colorHighlight, -1)
+	msg = strings.Replace(msg, highlightSuffix, colorReset, -1)
 
-		prefix := ""
-		header := fmt.Sprintf(
-			"\n%s %-18s %s %s",
-			colorUser,
-			fmt.Sprintf("%s:", from),
-			colorReset,
-			ts.Format(t.timeFormat),
-		)
+	return strings.Trim(msg, "\n")
+}
 
-		if section || t.lastPrefix == nil || t.lastPrefix.channel != channel {
-			l := runewidth.StringWidth(header) -
-				runewidth.StringWidth(colorUser) -
-				runewidth.StringWidth(colorReset) - 2
-			prefix = fmt.Sprintf(
-				"\n%s %-"+strconv.Itoa(l)+"s%s",
-				colorBgGreen,
-				channel,
-				colorReset,
-			)
-		}
+// Edited marks a formatted message body as edited.
+func (t *format) Edited(body string) string {
+	return fmt.Sprintf("%s %s(edited)%s", body, colorGray, colorReset)
+}
 
-		msg = fmt.Sprintf(
-			"%s%s\n%s",
-			prefix,
-			header,
-			msg,
-		)
+// Deleted returns the body of a deleted message.
+func (t *format) Deleted() string {
+	return fmt.Sprintf("%s[deleted]%s", colorGray, colorReset)
+}
+
+func (t *format) Delete(channel, from, msg string, ts time.Time) string {
+	t.mutex.Lock()
+	t.lastPrefix = nil
+	t.mutex.Unlock()
+
+	if from == "" {
+		from = "?"
+	}
+
+	if msg == "" {
+		msg = "?"
+	}
+
+	return fmt.Sprintf(
+		"%-18s %s deleted %s:\n%s- %s%s",
+		fmt.Sprintf("[%s]", channel),
+		from,
+		ts.Format(t.timeFormat),
+		colorRed,
+		colorReset,
+		t.markup(msg),
+	)
+}
+
+func (t *format) Edit(channel, from, prev, msg string, ts time.Time) string {
+	t.mutex.Lock()
+	t.lastPrefix = nil
+	t.mutex.Unlock()
+
+	if prev == "" {
+		prev = "?"
 	}
 
-	t.lastPrefix = &msgPrefix{channel, from, ts}
-	return msg
+	return fmt.Sprintf(
+		"%-18s %s edited %s:\n%s- %s%s\n%s+ %s%s",
+		fmt.Sprintf("[%s]", channel),
+		from,
+		ts.Format(t.timeFormat),
+		colorRed,
+		colorReset,
+		t.markup(prev),
+		colorGreen,
+		colorReset,
+		t.markup(msg),
+	)
 }
 
 func (t *format) File(channel, from, title, url string) string {
 	// TODO
 	//return t.Msg(channel, from, msg)
+	t.mutex.Lock()
 	t.lastPrefix = nil
+	t.mutex.Unlock()
 	return fmt.Sprintf(
 		"%-18s %s%-12s%s%s%s%s",
 		fmt.Sprintf("[%s]", channel),
@@ -3,6 +3,7 @@ package output
 import (
 	"log"
 	"os"
+	"regexp"
 	"time"
 
 	"github.com/frizinak/slek/slk"
@@ -30,10 +31,19 @@ func (s *Stdout) SetUsername(username string) {
 	s.format.setUsername(username)
 }
 
+// Highlight makes matches of re stand out in messages.
+func (s *Stdout) Highlight(re *regexp.Regexp) {
+	s.format.setHighlight(re)
+}
+
 func (s *Stdout) Notify(channel, from, text string, force bool) {
 	// noop
 }
 
+func (s *Stdout) DND(from, until time.Time) {
+	// noop
+}
+
 func (s *Stdout) Info(msg string) {
 	std.Println(s.format.Info(msg))
 }
@@ -46,8 +56,23 @@ func (s *Stdout) Warn(msg string) {
 	std.Println(s.format.Warn(msg))
 }
 
-func (s *Stdout) Msg(channel, from, msg string, ts time.Time, section bool) {
-	std.Println(s.format.Msg(channel, from, msg, ts, section))
+func (s *Stdout) Msg(
+	channel,
+	from,
+	msg string,
+	ts time.Time,
+	thread *slk.Thread,
+	section bool,
+) {
+	std.Println(s.format.Msg(channel, from, msg, ts, thread, section))
+}
+
+func (s *Stdout) Edit(channel, from, prev, msg string, ts time.Time) {
+	std.Println(s.format.Edit(channel, from, prev, msg, ts))
+}
+
+func (s *Stdout) Delete(channel, from, msg string, ts time.Time) {
+	std.Println(s.format.Delete(channel, from, msg, ts))
 }
 
 func (s *Stdout) File(channel, from, title, url string) {
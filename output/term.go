@@ -2,6 +2,7 @@ package output
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +19,9 @@ const (
 	viewChat   = "chat"
 	viewInput  = "input"
 	viewTyping = "typing"
+
+	// chatLimit is the amount of entries kept in the chat view.
+	chatLimit = 2000
 )
 
 type notification struct {
@@ -31,6 +35,34 @@ type view struct {
 	frame bool
 }
 
+// chatEntry is a single message / file in the chat view.
+// Messages are identified by channel and ts so they can be updated.
+type chatEntry struct {
+	channel string
+	ts      time.Time
+	// thread is true for thread replies, their body is indented.
+	thread bool
+	header string
+	body   string
+}
+
+// setBody replaces the body of the entry, indenting it if needed.
+func (c *chatEntry) setBody(f *format, body string) {
+	if c.thread {
+		body = f.indent(body)
+	}
+
+	c.body = body
+}
+
+func (c *chatEntry) String() string {
+	if c.header == "" {
+		return c.body
+	}
+
+	return fmt.Sprintf("%s\n%s", c.header, c.body)
+}
+
 // Term is a gocui / termbox slk.Output implementation that allows
 // for user input which is communicated over
 // the input channel as returned by NewTerm.
@@ -39,6 +71,7 @@ type Term struct {
 	appName string
 	appIcon string
 	input   chan string
+	keys    chan string
 	g       *gocui.Gui
 	// Since gocui.Execute spawns goroutines
 	// none of the update events are guaranteed to
@@ -49,9 +82,15 @@ type Term struct {
 	notificationLimit   time.Duration
 	notificationTimeout time.Duration
 
+	// dndMutex guards dndFrom and dndUntil.
+	dndMutex sync.Mutex
+	dndFrom  time.Time
+	dndUntil time.Time
+
 	clearTypingMutex sync.Mutex
 	clearTypingBox   *time.Time
 
+	// resetEventMutex guards resetEventBox and eventBoxCache.
 	resetEventMutex sync.Mutex
 	resetEventBox   *time.Time
 	eventBoxCache   string
@@ -61,6 +100,10 @@ type Term struct {
 	//typingWidth uint
 	dimensions map[string]uint
 	views      []*view
+
+	// chat contains the entries of the chat view, only accessed from
+	// within gQueue.
+	chat []*chatEntry
 }
 
 // NewTerm returns a Term and an input channel which will receive the current
@@ -84,6 +127,7 @@ func NewTerm(
 		appName:             appName,
 		appIcon:             appIcon,
 		input:               input,
+		keys:                make(chan string, 1),
 		gQueue:              queue,
 		notifyChan:          make(chan *notification, 1),
 		notificationLimit:   notificationLimit,
@@ -128,7 +172,9 @@ func (t *Term) Init() (err error) {
 					continue
 				}
 
-				t.Notify(n.channel, n.from, n.text, true)
+				if !t.inDND(time.Now()) {
+					t.notify(n.channel, n.from, n.text)
+				}
 				// Empty from, channel and text fields.
 				ns[i] = &notification{sent: true, created: n.created}
 			}
@@ -314,6 +360,11 @@ func (t *Term) SetUsername(username string) {
 	t.format.setUsername(username)
 }
 
+// Highlight makes matches of re stand out in messages.
+func (t *Term) Highlight(re *regexp.Regexp) {
+	t.format.setHighlight(re)
+}
+
 // BindKey allows binding a gocui.Key-press to the given handler.
 func (t *Term) BindKey(key gocui.Key, handler func() error) error {
 	h := func(g *gocui.Gui, v *gocui.View) error {
@@ -366,9 +417,10 @@ func (t *Term) Run() error {
 			}
 
 			t.resetEventBox = nil
+			cache := t.eventBoxCache
 			t.resetEventMutex.Unlock()
 
-			t.eventText(t.eventBoxCache, 0)
+			t.eventText(cache, 0)
 		}
 	}()
 
@@ -389,12 +441,35 @@ func (t *Term) Quit() {
 	}
 }
 
+// DND suppresses notifications between from and until.
+func (t *Term) DND(from, until time.Time) {
+	t.dndMutex.Lock()
+	t.dndFrom = from
+	t.dndUntil = until
+	t.dndMutex.Unlock()
+}
+
+func (t *Term) inDND(now time.Time) bool {
+	t.dndMutex.Lock()
+	defer t.dndMutex.Unlock()
+	return !now.Before(t.dndFrom) && now.Before(t.dndUntil)
+}
+
 func (t *Term) Notify(channel, from, text string, force bool) {
-	if !force {
-		t.notifyChan <- &notification{channel, from, text, time.Now(), false}
+	if force {
+		t.notify(channel, from, text)
 		return
 	}
 
+	if t.inDND(time.Now()) {
+		return
+	}
+
+	t.notifyChan <- &notification{channel, from, text, time.Now(), false}
+}
+
+// notify triggers a desktop notification.
+func (t *Term) notify(channel, from, text string) {
 	title := from
 	if from != channel {
 		title = fmt.Sprintf("%s: %s", channel, from)
@@ -437,12 +512,59 @@ func (t *Term) Warn(msg string) {
 	t.eventText(t.format.Warn(msg), time.Second*3)
 }
 
-func (t *Term) Msg(channel, from, msg string, ts time.Time, section bool) {
-	t.boxText(t.format.Msg(channel, from, msg, ts, section))
+func (t *Term) Msg(
+	channel,
+	from,
+	msg string,
+	ts time.Time,
+	thread *slk.Thread,
+	section bool,
+) {
+	header, body := t.format.msg(channel, from, msg, ts, thread, section)
+	t.chatText(
+		&chatEntry{
+			channel: channel,
+			ts:      ts,
+			thread:  thread != nil,
+			header:  header,
+			body:    body,
+		},
+	)
+}
+
+func (t *Term) Edit(channel, from, prev, msg string, ts time.Time) {
+	body := t.format.Edited(t.format.markup(msg))
+	t.gQueue <- func(g *gocui.Gui) error {
+		if entry := t.chatEntry(channel, ts); entry != nil {
+			entry.setBody(&t.format, body)
+			return t.redrawChat(g)
+		}
+
+		// Not (or no longer) in the chat view.
+		header, _ := t.format.msg(channel, from, msg, ts, nil, false)
+		t.appendChat(
+			g,
+			&chatEntry{channel: channel, ts: ts, header: header, body: body},
+		)
+		return nil
+	}
+}
+
+func (t *Term) Delete(channel, from, msg string, ts time.Time) {
+	body := t.format.Deleted()
+	t.gQueue <- func(g *gocui.Gui) error {
+		entry := t.chatEntry(channel, ts)
+		if entry == nil {
+			return nil
+		}
+
+		entry.setBody(&t.format, body)
+		return t.redrawChat(g)
+	}
 }
 
 func (t *Term) File(channel, from, title, url string) {
-	t.boxText(t.format.File(channel, from, title, url))
+	t.chatText(&chatEntry{body: t.format.File(channel, from, title, url)})
 }
 
 func (t *Term) Typing(channel, user string, timeout time.Duration) {
@@ -476,6 +598,7 @@ func (t *Term) Clear() {
 			return err
 		}
 
+		t.chat = nil
 		v.Clear()
 		v.SetOrigin(0, 0)
 		return nil
@@ -599,8 +722,66 @@ func (t *Term) text(which, msg string, clear bool) {
 	}
 }
 
-func (t *Term) boxText(msg string) {
-	t.text(viewChat, msg, false)
+func (t *Term) chatText(entry *chatEntry) {
+	t.gQueue <- func(g *gocui.Gui) error {
+		t.appendChat(g, entry)
+		return nil
+	}
+}
+
+// appendChat adds an entry to the chat view, should be called from
+// within gQueue.
+func (t *Term) appendChat(g *gocui.Gui, entry *chatEntry) {
+	t.chat = append(t.chat, entry)
+	if len(t.chat) > chatLimit {
+		t.chat = t.chat[len(t.chat)-chatLimit:]
+	}
+
+	v, _ := g.View(viewChat)
+	if v != nil {
+		fmt.Fprint(v, t.wrapChat(entry)+"\n")
+	}
+}
+
+func (t *Term) wrapChat(entry *chatEntry) string {
+	if width, ok := t.dimensions[viewChat]; ok {
+		return t.wrap(entry.String(), width)
+	}
+
+	return entry.String()
+}
+
+// chatEntry returns the last chat entry for the given message or nil,
+// should be called from within gQueue.
+func (t *Term) chatEntry(channel string, ts time.Time) *chatEntry {
+	for i := len(t.chat) - 1; i >= 0; i-- {
+		if t.chat[i].channel == channel && t.chat[i].ts.Equal(ts) {
+			return t.chat[i]
+		}
+	}
+
+	return nil
+}
+
+// redrawChat rewrites all chat entries while keeping the scroll position,
+// should be called from within gQueue.
+func (t *Term) redrawChat(g *gocui.Gui) error {
+	v, err := g.View(viewChat)
+	if err != nil {
+		return err
+	}
+
+	ox, oy := v.Origin()
+	v.Clear()
+	for _, entry := range t.chat {
+		fmt.Fprint(v, t.wrapChat(entry)+"\n")
+	}
+
+	if v.Autoscroll {
+		return nil
+	}
+
+	return v.SetOrigin(ox, oy)
 }
 
 func (t *Term) infoText(msg string) {
@@ -608,12 +789,12 @@ func (t *Term) infoText(msg string) {
 }
 
 func (t *Term) eventText(msg string, timeout time.Duration) {
+	t.resetEventMutex.Lock()
+	defer t.resetEventMutex.Unlock()
 	if timeout == 0 {
 		t.eventBoxCache = msg
 	} else {
 		at := time.Now().Add(timeout)
-		t.resetEventMutex.Lock()
-		defer t.resetEventMutex.Unlock()
 		if t.resetEventBox == nil || t.resetEventBox.Before(at) {
 			t.resetEventBox = &at
 		}
@@ -667,7 +848,7 @@ func (t *Term) layout(g *gocui.Gui) error {
 		v.Frame = true
 		v.Editable = true
 		v.Wrap = false
-		v.Editor = gocui.EditorFunc(editor)
+		v.Editor = gocui.EditorFunc(t.editor)
 	}
 
 	t.dimensions[viewEvent] = t.dimensions[viewChat]
@@ -695,7 +876,15 @@ func (t *Term) layout(g *gocui.Gui) error {
 	return nil
 }
 
-func editor(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+// Keys returns a channel that receives the contents of the input field
+// after each keypress that modified it. Contents are dropped if the channel
+// is not read from.
+func (t *Term) Keys() <-chan string {
+	return t.keys
+}
+
+func (t *Term) editor(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	modified := true
 	switch {
 	case ch != 0 && mod == 0:
 		v.EditWrite(ch)
@@ -705,6 +894,20 @@ func editor(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
 		v.EditDelete(true)
 	case key == gocui.KeyDelete:
 		v.EditDelete(false)
+	default:
+		modified = false
+	}
+
+	if modified {
+		select {
+		case t.keys <- v.Buffer():
+		default:
+		}
+
+		return
+	}
+
+	switch {
 	case key == gocui.KeyInsert:
 		v.Overwrite = !v.Overwrite
 	case key == gocui.KeyArrowDown:
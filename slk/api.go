@@ -0,0 +1,317 @@
+package slk
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+// API is the subset of the nlopes/slack web api Slk depends on.
+//
+// NewSlk uses an implementation backed by a *slack.Client, use
+// NewSlkWithAPI to run Slk against a different (e.g.: fake) backend.
+type API interface {
+	// GetUsers should return all users including their presence.
+	GetUsers() ([]User, error)
+	GetUserInfo(user string) (*User, error)
+	GetChannels(excludeArchived bool) ([]slack.Channel, error)
+	GetGroups(excludeArchived bool) ([]slack.Group, error)
+	GetIMChannels() ([]slack.IM, error)
+	// GetUserGroupsWithUsers should return all user groups including
+	// their members.
+	GetUserGroupsWithUsers() ([]UserGroup, error)
+
+	GetChannelHistory(
+		channel string,
+		params slack.HistoryParameters,
+	) (*slack.History, error)
+	GetGroupHistory(
+		group string,
+		params slack.HistoryParameters,
+	) (*slack.History, error)
+	GetIMHistory(
+		channel string,
+		params slack.HistoryParameters,
+	) (*slack.History, error)
+	GetMPIMHistory(
+		channel string,
+		params slack.HistoryParameters,
+	) (*slack.History, error)
+
+	SetChannelReadMark(channel, ts string) error
+	SetGroupReadMark(group, ts string) error
+	MarkIMChannel(channel, ts string) error
+	MarkMPIM(channel, ts string) error
+
+	PostMessage(
+		channel,
+		text string,
+		params slack.PostMessageParameters,
+	) (string, string, error)
+
+	// UpdateMessage should replace the text of the given message without
+	// escaping it and link @names.
+	UpdateMessage(
+		channel,
+		ts,
+		text string,
+	) (string, string, string, error)
+	DeleteMessage(channel, ts string) (string, string, error)
+
+	// GetConversationMembers should return a page of member ids of the
+	// given channel or group and the cursor of the next page, empty if this
+	// is the last one.
+	GetConversationMembers(
+		channel,
+		cursor string,
+	) ([]string, string, error)
+
+	// GetConversationReplies should return a page of the given thread
+	// (parent first) and the cursor of the next page, empty if this is the
+	// last one.
+	GetConversationReplies(
+		channel,
+		thread,
+		cursor string,
+	) ([]slack.Message, string, error)
+
+	AddReaction(name string, item slack.ItemRef) error
+	RemoveReaction(name string, item slack.ItemRef) error
+
+	SearchMessages(
+		query string,
+		params slack.SearchParameters,
+	) (*slack.SearchMessages, error)
+
+	ListPins(channel string) ([]slack.Item, *slack.Paging, error)
+	AddPin(channel string, item slack.ItemRef) error
+	RemovePin(channel string, item slack.ItemRef) error
+
+	ListStars(
+		params slack.StarsParameters,
+	) ([]slack.Item, *slack.Paging, error)
+	AddStar(channel string, item slack.ItemRef) error
+	RemoveStar(channel string, item slack.ItemRef) error
+
+	GetFiles(
+		params slack.GetFilesParameters,
+	) ([]slack.File, *slack.Paging, error)
+	UploadFile(params slack.FileUploadParameters) (*slack.File, error)
+
+	JoinChannel(channel string) (*slack.Channel, error)
+	LeaveChannel(channel string) (bool, error)
+	LeaveGroup(group string) error
+	OpenIMChannel(user string) (bool, bool, string, error)
+	// OpenMPIM should open (or create) the multi-party im with the given
+	// users, excluding ourselves.
+	OpenMPIM(users []string) (*slack.Group, error)
+	CloseMPIM(channel string) error
+	InviteUserToChannel(channel, user string) (*slack.Channel, error)
+	InviteUserToGroup(group, user string) (*slack.Group, bool, error)
+	CreateChannel(name string) (*slack.Channel, error)
+	CreateGroup(name string) (*slack.Group, error)
+	RenameChannel(channel, name string) (*slack.Channel, error)
+	RenameGroup(group, name string) (*slack.Channel, error)
+	ArchiveChannel(channel string) error
+	ArchiveGroup(group string) error
+	UnarchiveChannel(channel string) error
+	UnarchiveGroup(group string) error
+	KickUserFromChannel(channel, user string) error
+	KickUserFromGroup(group, user string) error
+	SetChannelTopic(channel, topic string) (string, error)
+	SetGroupTopic(group, topic string) (string, error)
+	SetChannelPurpose(channel, purpose string) (string, error)
+	SetGroupPurpose(group, purpose string) (string, error)
+
+	// AddReminder should create a reminder for the given user, empty
+	// meaning ourselves, at the given unix timestamp.
+	AddReminder(user, text, time string) (*Reminder, error)
+	ListReminders() ([]Reminder, error)
+	CompleteReminder(id string) error
+	DeleteReminder(id string) error
+
+	// GetUserPrefs should return our slack preferences, only string
+	// values are required.
+	GetUserPrefs() (map[string]string, error)
+	SetUserPresence(presence string) error
+	// SetUserCustomStatusWithExpiration should set our custom status,
+	// a 0 expiration never expires.
+	SetUserCustomStatusWithExpiration(
+		statusText,
+		statusEmoji string,
+		statusExpiration int64,
+	) error
+	GetDNDInfo(user *string) (*slack.DNDStatus, error)
+	GetDNDTeamInfo(users []string) (map[string]slack.DNDStatus, error)
+	SetSnooze(minutes int) (*slack.DNDStatus, error)
+	EndSnooze() (*slack.DNDStatus, error)
+	EndDND() error
+	SetUserAsActive() error
+
+	// NewRTM should return a real time messaging client that
+	// connects once ManageConnection is called.
+	NewRTM() RTM
+}
+
+// RTM is the subset of the nlopes/slack real time messaging api Slk
+// depends on.
+type RTM interface {
+	// ManageConnection should connect and keep the connection alive until
+	// Disconnect is called.
+	//
+	// A *slack.ConnectedEvent containing the team info should be written to
+	// the Events channel once connected.
+	ManageConnection()
+	Disconnect() error
+	// SendTyping should send a typing event for the given channel id.
+	SendTyping(channel string)
+	// Events should return the channel incoming events are written to.
+	// Events nlopes/slack has no type for should be written as
+	// *MemberChannelEvent or *SubteamEvent.
+	Events() <-chan slack.RTMEvent
+}
+
+// MemberChannelEvent is a member_joined_channel or member_left_channel
+// event.
+type MemberChannelEvent struct {
+	Type    string `json:"type"`
+	User    string `json:"user"`
+	Channel string `json:"channel"`
+}
+
+// SubteamEvent is one of the subteam_* events, all user groups are
+// refetched so only the type is of interest.
+type SubteamEvent struct {
+	Type string `json:"type"`
+}
+
+// User is a slack.User including the expiration of its custom status.
+type User struct {
+	slack.User
+	// StatusExpiration is the unix time the custom status expires at,
+	// 0 if it never does.
+	StatusExpiration int64
+}
+
+// UnmarshalJSON decodes a slack user object.
+func (u *User) UnmarshalJSON(b []byte) error {
+	p := &struct {
+		Profile struct {
+			StatusExpiration int64 `json:"status_expiration"`
+		} `json:"profile"`
+	}{}
+
+	if err := json.Unmarshal(b, &u.User); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, p); err != nil {
+		return err
+	}
+
+	u.StatusExpiration = p.Profile.StatusExpiration
+	return nil
+}
+
+// UserGroup is a slack.UserGroup including its members.
+type UserGroup struct {
+	slack.UserGroup
+	Users []string `json:"users"`
+}
+
+// Reminder is a slack reminder, Time is 0 for recurring reminders.
+type Reminder struct {
+	ID         string `json:"id"`
+	Creator    string `json:"creator"`
+	User       string `json:"user"`
+	Text       string `json:"text"`
+	Recurring  bool   `json:"recurring"`
+	Time       int64  `json:"time"`
+	CompleteTS int64  `json:"complete_ts"`
+}
+
+type slackAPI struct {
+	*slack.Client
+	token string
+}
+
+// NewAPI returns an API implementation backed by a *slack.Client.
+func NewAPI(token string) API {
+	return &slackAPI{slack.New(token), token}
+}
+
+func (s *slackAPI) NewRTM() RTM {
+	r := &slackRTM{
+		RTM:    s.Client.NewRTM(),
+		events: make(chan slack.RTMEvent, 50),
+		hello:  make(chan struct{}),
+	}
+
+	go r.forward()
+	return r
+}
+
+// rtmHelloTimeout is how long Disconnect waits for the hello event.
+const rtmHelloTimeout = time.Second * 5
+
+type slackRTM struct {
+	*slack.RTM
+	events chan slack.RTMEvent
+
+	// mutex guards hello which is closed once the current connection
+	// received the hello event.
+	mutex     sync.Mutex
+	hello     chan struct{}
+	helloSeen bool
+}
+
+// forward writes the library's events to our events channel and keeps
+// track of the hello event.
+func (r *slackRTM) forward() {
+	for e := range r.IncomingEvents {
+		switch e.Data.(type) {
+		case *slack.ConnectedEvent:
+			r.mutex.Lock()
+			if r.helloSeen {
+				r.hello = make(chan struct{})
+				r.helloSeen = false
+			}
+			r.mutex.Unlock()
+		case *slack.HelloEvent:
+			r.mutex.Lock()
+			if !r.helloSeen {
+				close(r.hello)
+				r.helloSeen = true
+			}
+			r.mutex.Unlock()
+		}
+
+		r.events <- e
+	}
+}
+
+func (r *slackRTM) Events() <-chan slack.RTMEvent {
+	return r.events
+}
+
+// Disconnect waits for the hello event of the current connection before
+// disconnecting, nlopes/slack only marks itself as connected after
+// emitting the connected event without synchronizing.
+func (r *slackRTM) Disconnect() error {
+	r.mutex.Lock()
+	hello := r.hello
+	r.mutex.Unlock()
+
+	select {
+	case <-hello:
+	case <-time.After(rtmHelloTimeout):
+	}
+
+	return r.RTM.Disconnect()
+}
+
+func (r *slackRTM) SendTyping(channel string) {
+	r.SendMessage(r.NewTypingMessage(channel))
+}
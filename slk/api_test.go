@@ -0,0 +1,104 @@
+package slk_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/frizinak/slek/slk"
+	"github.com/frizinak/slek/slk/slktest"
+	"github.com/nlopes/slack"
+)
+
+// injectAPI is an API whose rtm events can be written to directly.
+type injectAPI struct {
+	slk.API
+	rtm *injectRTM
+}
+
+func (a *injectAPI) NewRTM() slk.RTM {
+	a.rtm = &injectRTM{a.API.NewRTM(), make(chan slack.RTMEvent)}
+	go func() {
+		for e := range a.rtm.RTM.Events() {
+			a.rtm.events <- e
+		}
+	}()
+
+	return a.rtm
+}
+
+type injectRTM struct {
+	slk.RTM
+	events chan slack.RTMEvent
+}
+
+func (r *injectRTM) Events() <-chan slack.RTMEvent {
+	return r.events
+}
+
+func TestInjectedEvents(t *testing.T) {
+	srv := slktest.NewServer("U0", "me")
+	defer srv.Close()
+	srv.AddUser("U1", "bob")
+	srv.AddChannel("C1", "general", true, "U0", "U1")
+
+	api := &injectAPI{API: slk.NewAPI(slktest.Token)}
+	out := slktest.NewOutput()
+	s := slk.NewSlkWithAPI(api, "15:04", out)
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	go s.Run()
+	defer s.Quit()
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	s.Members(c, false)
+
+	api.rtm.events <- slack.RTMEvent{
+		Type: "member_left_channel",
+		Data: &slk.MemberChannelEvent{
+			Type:    "member_left_channel",
+			User:    "U1",
+			Channel: "C1",
+		},
+	}
+
+	bob := slack.User{ID: "U1", Name: "bob"}
+	bob.Profile.StatusEmoji = ":palm_tree:"
+	api.rtm.events <- slack.RTMEvent{
+		Type: "user_change",
+		Data: &slack.UserChangeEvent{Type: "user_change", User: bob},
+	}
+
+	api.rtm.events <- slack.RTMEvent{
+		Type: "subteam_created",
+		Data: &slk.SubteamEvent{Type: "subteam_created"},
+	}
+
+	for i := 0; i < 100 && len(srv.Calls("usergroups.list")) < 2; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if n := len(srv.Calls("usergroups.list")); n != 2 {
+		t.Errorf("expected user groups to be refetched, got %d calls", n)
+	}
+
+	out.Reset()
+	s.Members(c, false)
+	l := out.Wait("List", 1, wait)
+	if len(l) != 1 || len(l[0].Items) != 2 || l[0].Items[1].Value != "me" {
+		t.Errorf("members: %+v", out.Records(""))
+	}
+
+	out.Reset()
+	s.List(slk.TypeUser, false)
+	l = out.Wait("List", 1, wait)
+	found := false
+	for _, item := range l[0].Items {
+		found = found || item.Value == "bob                :palm_tree:"
+	}
+
+	if !found {
+		t.Errorf("user_change: %+v", l[0].Items)
+	}
+}
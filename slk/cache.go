@@ -1,8 +1,69 @@
 package slk
 
-import "github.com/nlopes/slack"
+import (
+	"sort"
+	"strings"
 
-func (s *Slk) updateUsers(users []slack.User) error {
+	"github.com/nlopes/slack"
+)
+
+// registry holds all known entities.
+// A registry is never modified once it has been published to Slk.reg,
+// updates create a (shallow) copy and replace the maps they touch.
+// This allows readers to iterate the maps without holding a lock.
+type registry struct {
+	users          map[string]*user
+	usersByName    map[string]*user
+	channels       map[string]*channel
+	channelsByName map[string]*channel
+	ims            map[string]*slack.IM
+	imsByUser      map[string]*slack.IM
+	mpims          map[string]*mpim
+	mpimsByName    map[string]*mpim
+	groups         map[string]*usergroup
+	groupsByHandle map[string]*usergroup
+}
+
+// usergroup is a user group that can be mentioned as @handle.
+type usergroup struct {
+	id      string
+	handle  string
+	members map[string]bool
+}
+
+func newRegistry() *registry {
+	return &registry{
+		map[string]*user{},
+		map[string]*user{},
+		map[string]*channel{},
+		map[string]*channel{},
+		map[string]*slack.IM{},
+		map[string]*slack.IM{},
+		map[string]*mpim{},
+		map[string]*mpim{},
+		map[string]*usergroup{},
+		map[string]*usergroup{},
+	}
+}
+
+// entities returns the current registry.
+func (s *Slk) entities() *registry {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.reg
+}
+
+// updateRegistry calls update with a copy of the current registry and
+// publishes it once update returns.
+func (s *Slk) updateRegistry(update func(reg *registry)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	reg := *s.reg
+	update(&reg)
+	s.reg = &reg
+}
+
+func (s *Slk) updateUsers(users []User) error {
 	if users == nil {
 		var err error
 		users, err = s.c.GetUsers()
@@ -11,17 +72,19 @@ func (s *Slk) updateUsers(users []slack.User) error {
 		}
 	}
 
-	_users := make(map[string]*user, len(users))
-	usersByName := make(map[string]*user, len(users))
+	s.updateRegistry(func(reg *registry) {
+		_users := make(map[string]*user, len(users))
+		usersByName := make(map[string]*user, len(users))
 
-	for i := range users {
-		u := slackUserToUser(&users[i], s.user(users[i].ID))
-		_users[users[i].ID] = u
-		usersByName[users[i].Name] = u
-	}
+		for i := range users {
+			u := slackUserToUser(&users[i], reg.user(users[i].ID))
+			_users[users[i].ID] = u
+			usersByName[users[i].Name] = u
+		}
 
-	s.users = _users
-	s.usersByName = usersByName
+		reg.users = _users
+		reg.usersByName = usersByName
+	})
 
 	return nil
 }
@@ -33,41 +96,60 @@ func (s *Slk) updateChannels(
 	var err error
 
 	if channels == nil {
-		channels, err = s.c.GetChannels(true)
+		channels, err = s.c.GetChannels(false)
 		if err != nil {
 			return err
 		}
 	}
 
 	if groups == nil {
-		groups, err = s.c.GetGroups(true)
+		groups, err = s.c.GetGroups(false)
 		if err != nil {
 			return err
 		}
 	}
 
-	_channels := make(map[string]*channel, len(channels))
-	channelsByName := make(map[string]*channel, len(channels))
-	for i := range channels {
-		_channels[channels[i].ID] = slackChannelToChannel(
-			&channels[i],
-			s.channel(channels[i].ID),
-		)
-	}
+	self := s.self()
+	s.updateRegistry(func(reg *registry) {
+		_channels := make(map[string]*channel, len(channels))
+		channelsByName := make(map[string]*channel, len(channels))
+		mpims := make(map[string]*mpim)
+		mpimsByName := make(map[string]*mpim)
+		for i := range channels {
+			_channels[channels[i].ID] = slackChannelToChannel(
+				&channels[i],
+				reg.channel(channels[i].ID),
+			)
+		}
 
-	for i := range groups {
-		_channels[groups[i].ID] = slackGroupToChannel(
-			&groups[i],
-			s.channel(groups[i].ID),
-		)
-	}
+		for i := range groups {
+			if isMPIM(groups[i].Name) {
+				m := slackGroupToMPIM(
+					&groups[i],
+					reg.mpimName(groups[i].Members, self),
+					reg.mpim(groups[i].ID),
+				)
 
-	for i := range _channels {
-		channelsByName[_channels[i].Name()] = _channels[i]
-	}
+				mpims[m.id] = m
+				mpimsByName[m.name] = m
+				continue
+			}
+
+			_channels[groups[i].ID] = slackGroupToChannel(
+				&groups[i],
+				reg.channel(groups[i].ID),
+			)
+		}
+
+		for i := range _channels {
+			channelsByName[_channels[i].Name()] = _channels[i]
+		}
 
-	s.channels = _channels
-	s.channelsByName = channelsByName
+		reg.channels = _channels
+		reg.channelsByName = channelsByName
+		reg.mpims = mpims
+		reg.mpimsByName = mpimsByName
+	})
 
 	return nil
 }
@@ -81,69 +163,190 @@ func (s *Slk) updateIMs(ims []slack.IM) error {
 		}
 	}
 
-	_ims := make(map[string]*slack.IM, len(ims))
-	_imsByUser := make(map[string]*slack.IM, len(ims))
-	for i := range ims {
-		_ims[ims[i].ID] = &ims[i]
-		_imsByUser[ims[i].User] = &ims[i]
-		u := s.user(ims[i].User)
-		if ims[i].LastRead != "" {
-			u.lastReadTs = ims[i].LastRead
-			u.unread = ims[i].UnreadCount
+	s.updateRegistry(func(reg *registry) {
+		_ims := make(map[string]*slack.IM, len(ims))
+		_imsByUser := make(map[string]*slack.IM, len(ims))
+		for i := range ims {
+			_ims[ims[i].ID] = &ims[i]
+			_imsByUser[ims[i].User] = &ims[i]
+			u := reg.user(ims[i].User)
+			if u.IsNil() {
+				continue
+			}
+
+			if ims[i].LastRead != "" {
+				u.setLastRead(ims[i].LastRead)
+				u.setUnread(ims[i].UnreadCount)
+			}
+			if ims[i].Latest != nil && ims[i].Latest.Timestamp != "" {
+				u.setLatest(ims[i].Latest.Timestamp)
+			}
 		}
-		if ims[i].Latest != nil && ims[i].Latest.Timestamp != "" {
-			u.latestTs = ims[i].Latest.Timestamp
+
+		reg.ims = _ims
+		reg.imsByUser = _imsByUser
+	})
+
+	return nil
+}
+
+func (s *Slk) updateUserGroups(groups []UserGroup) error {
+	if groups == nil {
+		var err error
+		groups, err = s.c.GetUserGroupsWithUsers()
+		if err != nil {
+			return err
 		}
 	}
 
-	s.ims = _ims
-	s.imsByUser = _imsByUser
+	s.updateRegistry(func(reg *registry) {
+		_groups := make(map[string]*usergroup, len(groups))
+		groupsByHandle := make(map[string]*usergroup, len(groups))
+		for i := range groups {
+			if groups[i].DateDelete != 0 {
+				continue
+			}
+
+			g := &usergroup{
+				groups[i].ID,
+				groups[i].Handle,
+				make(map[string]bool, len(groups[i].Users)),
+			}
+
+			for _, u := range groups[i].Users {
+				g.members[u] = true
+			}
+
+			_groups[g.id] = g
+			groupsByHandle[g.handle] = g
+		}
+
+		reg.groups = _groups
+		reg.groupsByHandle = groupsByHandle
+	})
 
 	return nil
 }
 
 func (s *Slk) user(id string) *user {
-	if u, ok := s.users[id]; ok {
+	return s.entities().user(id)
+}
+
+func (s *Slk) userByName(name string) *user {
+	return s.entities().userByName(name)
+}
+
+func (s *Slk) channel(id string) *channel {
+	return s.entities().channel(id)
+}
+
+func (s *Slk) channelByName(name string) *channel {
+	return s.entities().channelByName(name)
+}
+
+func (s *Slk) mpim(id string) *mpim {
+	return s.entities().mpim(id)
+}
+
+func (s *Slk) im(id string) *slack.IM {
+	return s.entities().im(id)
+}
+
+func (s *Slk) imByUser(id string) *slack.IM {
+	return s.entities().imByUser(id)
+}
+
+// entityByChannel returns the channel or mpim with the given id or the
+// user whose im has the given id.
+func (s *Slk) entityByChannel(id string) Entity {
+	return s.entities().entityByChannel(id)
+}
+
+func (r *registry) entityByChannel(id string) Entity {
+	if ch := r.channel(id); !ch.IsNil() {
+		return ch
+	}
+
+	if m := r.mpim(id); !m.IsNil() {
+		return m
+	}
+
+	return r.user(r.im(id).User)
+}
+
+// mpimName joins the sorted names of all members except self.
+func (r *registry) mpimName(members []string, self string) string {
+	names := make([]string, 0, len(members))
+	for _, id := range members {
+		if id != self {
+			names = append(names, r.user(id).Name())
+		}
+	}
+
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (r *registry) mpim(id string) *mpim {
+	if m, ok := r.mpims[id]; ok {
+		return m
+	}
+
+	return nilMPIM
+}
+
+// group returns the user group with the given id or nil.
+func (r *registry) group(id string) *usergroup {
+	return r.groups[id]
+}
+
+// groupByHandle returns the user group with the given handle or nil.
+func (r *registry) groupByHandle(handle string) *usergroup {
+	return r.groupsByHandle[handle]
+}
+
+func (r *registry) user(id string) *user {
+	if u, ok := r.users[id]; ok {
 		return u
 	}
 
 	return nilUser
 }
 
-func (s *Slk) userByName(name string) *user {
-	if u, ok := s.usersByName[name]; ok {
+func (r *registry) userByName(name string) *user {
+	if u, ok := r.usersByName[name]; ok {
 		return u
 	}
 
 	return nilUser
 }
 
-func (s *Slk) channel(id string) *channel {
-	if ch, ok := s.channels[id]; ok {
+func (r *registry) channel(id string) *channel {
+	if ch, ok := r.channels[id]; ok {
 		return ch
 	}
 
 	return nilChan
 }
 
-func (s *Slk) channelByName(name string) *channel {
-	if ch, ok := s.channelsByName[name]; ok {
+func (r *registry) channelByName(name string) *channel {
+	if ch, ok := r.channelsByName[name]; ok {
 		return ch
 	}
 
 	return nilChan
 }
 
-func (s *Slk) im(id string) *slack.IM {
-	if im, ok := s.ims[id]; ok {
+func (r *registry) im(id string) *slack.IM {
+	if im, ok := r.ims[id]; ok {
 		return im
 	}
 
 	return nilIM
 }
 
-func (s *Slk) imByUser(id string) *slack.IM {
-	if im, ok := s.imsByUser[id]; ok {
+func (r *registry) imByUser(id string) *slack.IM {
+	if im, ok := r.imsByUser[id]; ok {
 		return im
 	}
 
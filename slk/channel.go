@@ -1,6 +1,10 @@
 package slk
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 func (s *Slk) join(e Entity) error {
 	if e.Type() != TypeChannel {
@@ -12,6 +16,15 @@ func (s *Slk) join(e Entity) error {
 }
 
 func (s *Slk) leave(e Entity) error {
+	if e.Type() == TypeMPIM {
+		if err := s.c.CloseMPIM(e.ID()); err != nil {
+			return err
+		}
+
+		e.(*mpim).setOpen(false)
+		return nil
+	}
+
 	if e.Type() != TypeChannel {
 		return fmt.Errorf("Can not leave a %s", e.Type())
 	}
@@ -50,6 +63,218 @@ func (s *Slk) invite(chn, user Entity) error {
 	return err
 }
 
+// membersTTL is how long a full member fetch is considered accurate.
+const membersTTL = time.Minute * 10
+
+// syncMembers fetches all pages of members of the given channel unless
+// they were fetched less than membersTTL ago.
+func (s *Slk) syncMembers(ch *channel) error {
+	if ch.membersSynced(time.Now().Add(-membersTTL)) {
+		return nil
+	}
+
+	members := make([]string, 0)
+	var cursor string
+	for {
+		page, next, err := s.c.GetConversationMembers(ch.ID(), cursor)
+		if err != nil {
+			return err
+		}
+
+		members = append(members, page...)
+		if next == "" {
+			break
+		}
+
+		cursor = next
+	}
+
+	ch.setMembers(members)
+	return nil
+}
+
+// create creates a public channel or private group.
+func (s *Slk) create(name string, private bool) (Entity, error) {
+	var id string
+	if private {
+		g, err := s.c.CreateGroup(name)
+		if err != nil {
+			return nil, err
+		}
+
+		id = g.ID
+	} else {
+		c, err := s.c.CreateChannel(name)
+		if err != nil {
+			return nil, err
+		}
+
+		id = c.ID
+	}
+
+	if err := s.updateChannels(nil, nil); err != nil {
+		return nil, err
+	}
+
+	ch := s.channel(id)
+	if ch.IsNil() {
+		return nil, fmt.Errorf("Unknown channel %s", id)
+	}
+
+	return ch, nil
+}
+
+func (s *Slk) rename(e Entity, name string) error {
+	if e.Type() != TypeChannel {
+		return fmt.Errorf("Can not rename a %s", e.Type())
+	}
+
+	var err error
+	if e.(*channel).isChannel {
+		_, err = s.c.RenameChannel(e.ID(), name)
+	} else {
+		_, err = s.c.RenameGroup(e.ID(), name)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.updateChannels(nil, nil)
+}
+
+func (s *Slk) archive(e Entity, archive bool) error {
+	if e.Type() != TypeChannel {
+		if archive {
+			return fmt.Errorf("Can not archive a %s", e.Type())
+		}
+
+		return fmt.Errorf("Can not unarchive a %s", e.Type())
+	}
+
+	ch := e.(*channel)
+	var err error
+	switch {
+	case ch.isChannel && archive:
+		err = s.c.ArchiveChannel(ch.ID())
+	case ch.isChannel:
+		err = s.c.UnarchiveChannel(ch.ID())
+	case archive:
+		err = s.c.ArchiveGroup(ch.ID())
+	default:
+		err = s.c.UnarchiveGroup(ch.ID())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ch.setArchived(archive)
+	return nil
+}
+
+func (s *Slk) kick(chn, user Entity) error {
+	if chn.Type() != TypeChannel {
+		return fmt.Errorf("Can not kick someone from a %s", chn.Type())
+	}
+
+	if user.Type() != TypeUser {
+		return fmt.Errorf("Can not kick a %s", user.Type())
+	}
+
+	ch := chn.(*channel)
+	var err error
+	if ch.isChannel {
+		err = s.c.KickUserFromChannel(ch.ID(), user.ID())
+	} else {
+		err = s.c.KickUserFromGroup(ch.ID(), user.ID())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ch.removeMember(user.ID())
+	return nil
+}
+
+func (s *Slk) setTopic(e Entity, topic string) error {
+	if e.Type() != TypeChannel {
+		return fmt.Errorf("Can not set the topic of a %s", e.Type())
+	}
+
+	ch := e.(*channel)
+	var err error
+	if ch.isChannel {
+		topic, err = s.c.SetChannelTopic(ch.ID(), topic)
+	} else {
+		topic, err = s.c.SetGroupTopic(ch.ID(), topic)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ch.setTopic(topic)
+	return nil
+}
+
+func (s *Slk) setPurpose(e Entity, purpose string) error {
+	if e.Type() != TypeChannel {
+		return fmt.Errorf("Can not set the purpose of a %s", e.Type())
+	}
+
+	ch := e.(*channel)
+	var err error
+	if ch.isChannel {
+		purpose, err = s.c.SetChannelPurpose(ch.ID(), purpose)
+	} else {
+		purpose, err = s.c.SetGroupPurpose(ch.ID(), purpose)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ch.setPurpose(purpose)
+	return nil
+}
+
+// openMPIM opens the multi-party im with the given users.
+func (s *Slk) openMPIM(users []Entity) (Entity, error) {
+	if len(users) < 2 {
+		return nil, errors.New("An mpim needs at least 2 other users")
+	}
+
+	ids := make([]string, len(users))
+	for i := range users {
+		if users[i].Type() != TypeUser {
+			return nil, fmt.Errorf("Can not add a %s to an mpim", users[i].Type())
+		}
+
+		ids[i] = users[i].ID()
+	}
+
+	g, err := s.c.OpenMPIM(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	m := s.mpim(g.ID)
+	if m.IsNil() {
+		if err := s.updateChannels(nil, nil); err != nil {
+			return nil, err
+		}
+
+		if m = s.mpim(g.ID); m.IsNil() {
+			return nil, fmt.Errorf("Unknown mpim %s", g.ID)
+		}
+	}
+
+	m.setOpen(true)
+	return m, nil
+}
+
 // Mark the last read message in an IM, channel or group.
 func (s *Slk) mark(e Entity) error {
 	var err error
@@ -66,6 +291,8 @@ func (s *Slk) mark(e Entity) error {
 		err = s.c.SetGroupReadMark(e.ID(), latest)
 	case TypeUser:
 		err = s.c.MarkIMChannel(s.imByUser(e.ID()).ID, latest)
+	case TypeMPIM:
+		err = s.c.MarkMPIM(e.ID(), latest)
 	default:
 		err = fmt.Errorf("Can't mark a %s", e.Type())
 	}
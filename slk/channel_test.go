@@ -0,0 +1,286 @@
+package slk_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/frizinak/slek/slk"
+	"github.com/frizinak/slek/slk/slktest"
+)
+
+func TestMPIM(t *testing.T) {
+	srv := slktest.NewServer("U0", "me")
+	defer srv.Close()
+	srv.AddUser("U1", "bob")
+	srv.AddUser("U2", "alice")
+	srv.AddUser("U3", "carol")
+	srv.AddChannel("C1", "general", true, "U0", "U1")
+	srv.AddMPIM("G1", "U1", "U2")
+	srv.AddMessage("G1", "U1", "old")
+
+	out := slktest.NewOutput()
+	s := slk.NewSlk(slktest.Token, "15:04", out)
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	go s.Run()
+	defer s.Quit()
+
+	m := entity(t, s, slk.TypeMPIM, "bob")
+	if m.QualifiedName() != "&alice,bob" {
+		t.Errorf("mpim: %s", m.QualifiedName())
+	}
+
+	s.Switch(entity(t, s, slk.TypeChannel, "general"))
+	srv.SendMessage("G1", "U2", "hey all")
+	n := out.Wait("Notify", 1, wait)
+	if len(n) != 1 || n[0].Channel != "&alice,bob" {
+		t.Fatalf("notify: %+v", out.Records(""))
+	}
+
+	s.Switch(m)
+	out.Reset()
+	if err := s.History(m, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	h := out.Wait("Msg", 2, wait)
+	if len(h) != 2 || h[0].Text != "old" || h[1].Text != "hey all" {
+		t.Errorf("history: %+v", out.Records(""))
+	}
+
+	if err := s.Post(m, "yo"); err != nil {
+		t.Fatal(err)
+	}
+
+	c := srv.Calls("chat.postMessage")
+	if len(c) != 1 || c[0].Values.Get("channel") != "G1" {
+		t.Errorf("post: %+v", c)
+	}
+
+	out.Reset()
+	s.List(slk.TypeMPIM, false)
+	l := out.Wait("List", 1, wait)
+	if len(l) != 1 || len(l[0].Items) != 2 || l[0].Items[1].Value != "alice,bob" {
+		t.Errorf("list: %+v", out.Records(""))
+	}
+
+	if err := s.Leave(m); err != nil {
+		t.Fatal(err)
+	}
+
+	if m = entity(t, s, slk.TypeMPIM, "bob"); m.IsActive() {
+		t.Error("left mpim still open")
+	}
+
+	carol := entity(t, s, slk.TypeUser, "carol")
+	bob := entity(t, s, slk.TypeUser, "bob")
+	opened, err := s.OpenMPIM(carol, bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if opened.QualifiedName() != "&bob,carol" || !opened.IsActive() {
+		t.Errorf("opened: %s", opened.QualifiedName())
+	}
+
+	if len(s.Fuzzy(slk.TypeMPIM, "carol")) != 1 {
+		t.Error("opened mpim is not registered")
+	}
+}
+
+func TestTopic(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	bob := entity(t, s, slk.TypeUser, "bob")
+	s.Switch(c)
+	if err := s.SetTopic(c, "release <@U1>"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.SetPurpose(c, "chatting"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.SetTopic(bob, "nope"); err == nil {
+		t.Error("set the topic of a user")
+	}
+
+	switchInfo := func() string {
+		s.Switch(bob)
+		out.Reset()
+		s.Switch(c)
+		i := out.Wait("Info", 1, wait)
+		if len(i) != 1 {
+			t.Fatalf("switch: %+v", out.Records(""))
+		}
+
+		return i[0].Text
+	}
+
+	if i := switchInfo(); i != "#general: release @bob" {
+		t.Errorf("topic: %s", i)
+	}
+
+	srv.Send(
+		map[string]interface{}{
+			"type":    "message",
+			"subtype": "channel_topic",
+			"channel": "C1",
+			"user":    "U1",
+			"text":    "set the channel topic: live",
+			"ts":      srv.Ts(),
+			"topic":   "live",
+		},
+	)
+	out.Wait("Msg", 1, wait)
+
+	if i := switchInfo(); i != "#general: live" {
+		t.Errorf("live topic: %s", i)
+	}
+
+	s.Members(c, false)
+	l := out.Wait("List", 1, wait)
+	if len(l) != 1 || len(l[0].Items) < 2 || l[0].Items[1].Value != "chatting" {
+		t.Errorf("purpose: %+v", out.Records(""))
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	e, err := s.Create("newroom", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e.QualifiedName() != "#newroom" || !e.IsActive() {
+		t.Errorf("created: %s", e.QualifiedName())
+	}
+
+	if _, err := s.Create("secret", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if c := srv.Calls("groups.create"); len(c) != 1 || c[0].Values.Get("name") != "secret" {
+		t.Errorf("groups.create: %+v", c)
+	}
+
+	entity(t, s, slk.TypeChannel, "secret")
+	if err := s.Rename(e, "renamed"); err != nil {
+		t.Fatal(err)
+	}
+
+	e = entity(t, s, slk.TypeChannel, "renamed")
+	if err := s.Archive(e); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	s.Switch(e)
+	i := out.Wait("Info", 1, wait)
+	if len(i) != 1 || i[0].Text != "#renamed [archived]" {
+		t.Errorf("archived: %+v", out.Records(""))
+	}
+
+	if err := s.Unarchive(e); err != nil {
+		t.Fatal(err)
+	}
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	bob := entity(t, s, slk.TypeUser, "bob")
+	if err := s.Kick(c, bob); err != nil {
+		t.Fatal(err)
+	}
+
+	k := srv.Calls("channels.kick")
+	if len(k) != 1 || k[0].Values.Get("channel") != "C1" || k[0].Values.Get("user") != "U1" {
+		t.Errorf("channels.kick: %+v", k)
+	}
+
+	out.Reset()
+	if err := s.Kick(bob, c); err == nil {
+		t.Error("kicked a channel from a user")
+	}
+
+	if w := out.Records("Warn"); len(w) != 1 {
+		t.Errorf("warnings: %+v", w)
+	}
+}
+
+func TestMembers(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	members := []string{"U0", "U1"}
+	for i := 0; i < 150; i++ {
+		members = append(members, fmt.Sprintf("X%d", i))
+	}
+	srv.SetMembers("C1", members...)
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	listed := func() (int, bool) {
+		out.Reset()
+		s.Members(c, false)
+		l := out.Wait("List", 1, wait)
+		if len(l) != 1 {
+			t.Fatalf("members: %+v", out.Records(""))
+		}
+
+		bob := false
+		for _, item := range l[0].Items[1:] {
+			bob = bob || item.Value == "bob"
+		}
+
+		return len(l[0].Items) - 1, bob
+	}
+
+	if n, bob := listed(); n != len(members) || !bob {
+		t.Errorf("expected %d members got %d", len(members), n)
+	}
+
+	if calls := srv.Calls("conversations.members"); len(calls) != 2 {
+		t.Errorf("expected 2 pages of members, got %d", len(calls))
+	}
+
+	member := func(typ string, joined bool) {
+		srv.Send(
+			map[string]interface{}{
+				"type":    typ,
+				"user":    "U1",
+				"channel": "C1",
+			},
+		)
+
+		n, bob := listed()
+		for i := 0; i < 100 && bob != joined; i++ {
+			time.Sleep(time.Millisecond * 10)
+			n, bob = listed()
+		}
+
+		exp := len(members)
+		if !joined {
+			exp--
+		}
+
+		if bob != joined || n != exp {
+			t.Errorf("%s: %d members", typ, n)
+		}
+	}
+
+	member("member_left_channel", false)
+	member("member_joined_channel", true)
+
+	// Synced members are kept up to date by events instead of refetched.
+	if calls := srv.Calls("conversations.members"); len(calls) != 2 {
+		t.Errorf("members refetched: %d calls", len(calls))
+	}
+}
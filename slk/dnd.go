@@ -0,0 +1,137 @@
+package slk
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+// dndBatchSize is the maximum amount of users dnd.teamInfo accepts.
+const dndBatchSize = 50
+
+// dndWindow returns the period during which st suppresses notifications,
+// a zero from means since forever, a zero until means DND is off.
+func dndWindow(st *slack.DNDStatus, now time.Time) (from, until time.Time) {
+	if st.SnoozeEnabled && int64(st.SnoozeEndTime) > now.Unix() {
+		return time.Time{}, time.Unix(int64(st.SnoozeEndTime), 0)
+	}
+
+	if st.Enabled && int64(st.NextEndTimestamp) > now.Unix() {
+		return time.Unix(int64(st.NextStartTimestamp), 0),
+			time.Unix(int64(st.NextEndTimestamp), 0)
+	}
+
+	return time.Time{}, time.Time{}
+}
+
+// updateDND fetches the DND status of all users and our own snooze state.
+func (s *Slk) updateDND() error {
+	reg := s.entities()
+	ids := make([]string, 0, len(reg.users))
+	for id := range reg.users {
+		ids = append(ids, id)
+	}
+
+	for len(ids) != 0 {
+		n := dndBatchSize
+		if n > len(ids) {
+			n = len(ids)
+		}
+
+		statuses, err := s.c.GetDNDTeamInfo(ids[:n])
+		if err != nil {
+			return err
+		}
+
+		for id := range statuses {
+			st := statuses[id]
+			reg.user(id).setDND(&st)
+		}
+
+		ids = ids[n:]
+	}
+
+	st, err := s.c.GetDNDInfo(nil)
+	if err != nil {
+		return err
+	}
+
+	s.dndUpdated(s.self(), st)
+	return nil
+}
+
+// dndUpdated stores the DND status of the given user and notifies the
+// Output if our own changed.
+func (s *Slk) dndUpdated(userID string, st *slack.DNDStatus) {
+	u := s.user(userID)
+	prev := u.dndStatus()
+	u.setDND(st)
+	if userID != s.self() {
+		return
+	}
+
+	now := time.Now()
+	prevFrom, prevUntil := dndWindow(&prev, now)
+	from, until := dndWindow(st, now)
+	if from.Equal(prevFrom) && until.Equal(prevUntil) {
+		return
+	}
+
+	s.out.DND(from, until)
+	if until.IsZero() {
+		s.out.Notice("Do not disturb off")
+		return
+	}
+
+	if from.After(now) {
+		s.out.Notice(
+			fmt.Sprintf(
+				"Do not disturb from %s until %s",
+				from.Format(s.timeFormat),
+				until.Format(s.timeFormat),
+			),
+		)
+		return
+	}
+
+	s.out.Notice(
+		fmt.Sprintf("Do not disturb until %s", until.Format(s.timeFormat)),
+	)
+}
+
+func (s *Slk) snooze(minutes int) error {
+	if minutes < 1 {
+		return fmt.Errorf("Invalid amount of minutes: %d", minutes)
+	}
+
+	st, err := s.c.SetSnooze(minutes)
+	if err != nil {
+		return err
+	}
+
+	s.dndUpdated(s.self(), st)
+	return nil
+}
+
+// endDND ends the current snooze or, if we are not snoozing, the current
+// scheduled DND session.
+func (s *Slk) endDND() error {
+	u := s.user(s.self())
+	if st := u.dndStatus(); st.SnoozeEnabled {
+		st, err := s.c.EndSnooze()
+		if err != nil {
+			return err
+		}
+
+		s.dndUpdated(u.ID(), st)
+		return nil
+	}
+
+	if err := s.c.EndDND(); err != nil {
+		return err
+	}
+
+	s.dndUpdated(u.ID(), &slack.DNDStatus{})
+	return nil
+}
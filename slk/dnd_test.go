@@ -0,0 +1,79 @@
+package slk_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/frizinak/slek/slk"
+	"github.com/nlopes/slack"
+)
+
+func TestDND(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	if d := out.Records("DND"); len(d) != 0 {
+		t.Errorf("dnd without a status: %+v", d)
+	}
+
+	bobStatus := func() int {
+		out.Reset()
+		s.List(slk.TypeUser, false)
+		for _, item := range out.Wait("List", 1, wait)[0].Items {
+			if item.Value == "bob" {
+				return item.Status
+			}
+		}
+
+		t.Fatal("bob not listed")
+		return 0
+	}
+
+	now := time.Now().Unix()
+	srv.SetDND(
+		"U1",
+		slack.DNDStatus{
+			Enabled:            true,
+			NextStartTimestamp: int(now - 60),
+			NextEndTimestamp:   int(now + 600),
+		},
+	)
+
+	status := bobStatus()
+	for i := 0; i < 100 && status != slk.ListItemStatusBad; i++ {
+		time.Sleep(time.Millisecond * 10)
+		status = bobStatus()
+	}
+
+	if status != slk.ListItemStatusBad {
+		t.Errorf("bob in dnd listed as %v", status)
+	}
+
+	out.Reset()
+	if err := s.Snooze(10); err != nil {
+		t.Fatal(err)
+	}
+
+	d := out.Wait("DND", 1, wait)
+	if len(d) != 1 || d[0].TS.Before(time.Now().Add(time.Minute*9)) {
+		t.Fatalf("snooze: %+v", out.Records(""))
+	}
+
+	if err := s.EndDND(); err != nil {
+		t.Fatal(err)
+	}
+
+	d = out.Wait("DND", 2, wait)
+	if len(d) != 2 || !d[1].TS.IsZero() {
+		t.Fatalf("end: %+v", out.Records(""))
+	}
+
+	if c := srv.Calls("dnd.endSnooze"); len(c) != 1 {
+		t.Errorf("dnd.endSnooze: %+v", c)
+	}
+
+	if err := s.Snooze(0); err == nil {
+		t.Error("snoozed for 0 minutes")
+	}
+}
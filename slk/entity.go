@@ -1,6 +1,12 @@
 package slk
 
-import "github.com/nlopes/slack"
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+)
 
 const (
 	// UserPresenceActive represents the active presence.
@@ -12,6 +18,8 @@ const (
 	TypeChannel EntityType = "channel"
 	// TypeUser identifies the user-type
 	TypeUser EntityType = "user"
+	// TypeMPIM identifies the multi-party im-type
+	TypeMPIM EntityType = "mpim"
 )
 
 const (
@@ -35,6 +43,12 @@ var (
 		members: []string{},
 	}
 
+	nilMPIM = &mpim{
+		id:      nilID,
+		name:    nilName,
+		members: []string{},
+	}
+
 	nilIM = &slack.IM{User: nilID}
 )
 
@@ -48,7 +62,7 @@ type UserPresence string
 // EntityType represents a slack entity-type (i.e.: channel, user, ...)
 type EntityType string
 
-// Entity abstracts users, groups and channels
+// Entity abstracts users, groups, channels and multi-party ims
 type Entity interface {
 	ID() string
 	Name() string
@@ -69,19 +83,74 @@ type Entity interface {
 	resetUnread()
 }
 
+// entity holds the mutable state shared by all entity types.
+// mutex also guards the mutable fields of the types embedding it.
 type entity struct {
+	mutex      sync.RWMutex
 	unread     int
 	lastReadTs string
 	latestTs   string
 }
 
-func (e *entity) UnreadCount() int     { return e.unread }
-func (e *entity) lastRead() string     { return e.lastReadTs }
-func (e *entity) latest() string       { return e.latestTs }
-func (e *entity) setLastRead(l string) { e.lastReadTs = l }
-func (e *entity) setLatest(l string)   { e.latestTs = l }
-func (e *entity) incrementUnread()     { e.unread++ }
-func (e *entity) resetUnread()         { e.unread = 0 }
+func (e *entity) UnreadCount() int {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.unread
+}
+
+func (e *entity) lastRead() string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.lastReadTs
+}
+
+func (e *entity) latest() string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.latestTs
+}
+
+func (e *entity) setLastRead(l string) {
+	e.mutex.Lock()
+	e.lastReadTs = l
+	e.mutex.Unlock()
+}
+
+func (e *entity) setLatest(l string) {
+	e.mutex.Lock()
+	e.latestTs = l
+	e.mutex.Unlock()
+}
+
+func (e *entity) setUnread(n int) {
+	e.mutex.Lock()
+	e.unread = n
+	e.mutex.Unlock()
+}
+
+func (e *entity) incrementUnread() {
+	e.mutex.Lock()
+	e.unread++
+	e.mutex.Unlock()
+}
+
+func (e *entity) resetUnread() { e.setUnread(0) }
+
+// inherit copies the read state of original if we did not receive any.
+func (e *entity) inherit(original *entity) {
+	original.mutex.RLock()
+	defer original.mutex.RUnlock()
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.lastReadTs != "" {
+		return
+	}
+
+	e.lastReadTs = original.lastReadTs
+	e.latestTs = original.latestTs
+	e.unread = original.unread
+}
 
 type channel struct {
 	entity
@@ -91,13 +160,18 @@ type channel struct {
 	members   []string
 	isChannel bool
 	isMember  bool
+	archived  bool
+	topic     string
+	purpose   string
+	// synced is the last time members was fetched in full.
+	synced time.Time
 }
 
 func (c *channel) ID() string            { return c.id }
 func (c *channel) Name() string          { return c.name }
 func (c *channel) QualifiedName() string { return "#" + c.name }
 func (c *channel) Type() EntityType      { return TypeChannel }
-func (c *channel) IsActive() bool        { return c.isMember }
+func (c *channel) IsActive() bool        { return c.member() }
 func (c *channel) IsAway() bool          { return false }
 func (c *channel) IsNil() bool           { return c.id == nilID }
 func (c *channel) Is(entity Entity) bool {
@@ -105,23 +179,218 @@ func (c *channel) Is(entity Entity) bool {
 		c.id == entity.ID() && entity.Type() == c.Type()
 }
 
+func (c *channel) member() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.isMember
+}
+
+func (c *channel) setMember(isMember bool) {
+	if c.IsNil() {
+		return
+	}
+
+	c.mutex.Lock()
+	c.isMember = isMember
+	c.mutex.Unlock()
+}
+
+func (c *channel) isArchived() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.archived
+}
+
+func (c *channel) setArchived(archived bool) {
+	if c.IsNil() {
+		return
+	}
+
+	c.mutex.Lock()
+	c.archived = archived
+	c.mutex.Unlock()
+}
+
+func (c *channel) info() (topic, purpose string) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.topic, c.purpose
+}
+
+func (c *channel) setTopic(topic string) {
+	if c.IsNil() {
+		return
+	}
+
+	c.mutex.Lock()
+	c.topic = topic
+	c.mutex.Unlock()
+}
+
+func (c *channel) setPurpose(purpose string) {
+	if c.IsNil() {
+		return
+	}
+
+	c.mutex.Lock()
+	c.purpose = purpose
+	c.mutex.Unlock()
+}
+
+func (c *channel) memberIDs() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	members := make([]string, len(c.members))
+	copy(members, c.members)
+	return members
+}
+
+// membersSynced reports whether members was fetched in full after since.
+func (c *channel) membersSynced(since time.Time) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.synced.After(since)
+}
+
+func (c *channel) setMembers(members []string) {
+	if c.IsNil() {
+		return
+	}
+
+	c.mutex.Lock()
+	c.members = members
+	c.synced = time.Now()
+	c.mutex.Unlock()
+}
+
+func (c *channel) addMember(id string) {
+	if c.IsNil() {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i := range c.members {
+		if c.members[i] == id {
+			return
+		}
+	}
+
+	c.members = append(c.members, id)
+}
+
+func (c *channel) removeMember(id string) {
+	if c.IsNil() {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i := range c.members {
+		if c.members[i] == id {
+			c.members = append(c.members[:i], c.members[i+1:]...)
+			return
+		}
+	}
+}
+
+// mpim is a multi-party im, named after its members.
+type mpim struct {
+	entity
+	id      string
+	name    string
+	members []string
+	isOpen  bool
+}
+
+func (m *mpim) ID() string            { return m.id }
+func (m *mpim) Name() string          { return m.name }
+func (m *mpim) QualifiedName() string { return "&" + m.name }
+func (m *mpim) Type() EntityType      { return TypeMPIM }
+func (m *mpim) IsActive() bool        { return m.open() }
+func (m *mpim) IsAway() bool          { return false }
+func (m *mpim) IsNil() bool           { return m.id == nilID }
+func (m *mpim) Is(entity Entity) bool {
+	return entity != nil &&
+		m.id == entity.ID() && entity.Type() == m.Type()
+}
+
+func (m *mpim) open() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.isOpen
+}
+
+func (m *mpim) setOpen(isOpen bool) {
+	if m.IsNil() {
+		return
+	}
+
+	m.mutex.Lock()
+	m.isOpen = isOpen
+	m.mutex.Unlock()
+}
+
 type user struct {
 	*slack.User
 	entity
+	dnd slack.DNDStatus
+	// statusExpiration is the unix time the custom status expires at.
+	statusExpiration int64
 }
 
 func (u *user) ID() string            { return u.User.ID }
 func (u *user) Name() string          { return u.User.Name }
 func (u *user) QualifiedName() string { return "@" + u.User.Name }
 func (u *user) Type() EntityType      { return TypeUser }
-func (u *user) IsActive() bool        { return u.Presence == string(UserPresenceActive) }
-func (u *user) IsAway() bool          { return u.Presence == string(UserPresenceAway) }
+func (u *user) IsActive() bool        { return u.presence() == string(UserPresenceActive) }
+func (u *user) IsAway() bool          { return u.presence() == string(UserPresenceAway) }
 func (u *user) IsNil() bool           { return u.User.ID == nilID }
 func (u *user) Is(entity Entity) bool {
 	return entity != nil &&
 		u.User.ID == entity.ID() && entity.Type() == u.Type()
 }
 
+func (u *user) dndStatus() slack.DNDStatus {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+	return u.dnd
+}
+
+func (u *user) setDND(st *slack.DNDStatus) {
+	if u.IsNil() {
+		return
+	}
+
+	u.mutex.Lock()
+	u.dnd = *st
+	u.mutex.Unlock()
+}
+
+// inDND reports whether the user currently does not want to be disturbed.
+func (u *user) inDND() bool {
+	st := u.dndStatus()
+	now := time.Now()
+	from, until := dndWindow(&st, now)
+	return !now.Before(from) && now.Before(until)
+}
+
+func (u *user) presence() string {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+	return u.Presence
+}
+
+func (u *user) setPresence(presence string) {
+	if u.IsNil() {
+		return
+	}
+
+	u.mutex.Lock()
+	u.Presence = presence
+	u.mutex.Unlock()
+}
+
 func slackChannelToChannel(c *slack.Channel, original *channel) *channel {
 	ch := &channel{
 		id:        c.ID,
@@ -130,6 +399,9 @@ func slackChannelToChannel(c *slack.Channel, original *channel) *channel {
 		members:   c.Members,
 		isChannel: true,
 		isMember:  c.IsMember,
+		archived:  c.IsArchived,
+		topic:     c.Topic.Value,
+		purpose:   c.Purpose.Value,
 
 		entity: entity{
 			lastReadTs: c.LastRead,
@@ -145,10 +417,8 @@ func slackChannelToChannel(c *slack.Channel, original *channel) *channel {
 		ch.latestTs = c.Latest.Timestamp
 	}
 
-	if original != nil && !original.IsNil() && ch.lastReadTs == "" {
-		ch.lastReadTs = original.lastReadTs
-		ch.latestTs = original.latestTs
-		ch.unread = original.unread
+	if original != nil && !original.IsNil() {
+		ch.inherit(&original.entity)
 	}
 
 	return ch
@@ -162,6 +432,9 @@ func slackGroupToChannel(g *slack.Group, original *channel) *channel {
 		members:   g.Members,
 		isChannel: false,
 		isMember:  true,
+		archived:  g.IsArchived,
+		topic:     g.Topic.Value,
+		purpose:   g.Purpose.Value,
 
 		entity: entity{
 			lastReadTs: g.LastRead,
@@ -177,22 +450,57 @@ func slackGroupToChannel(g *slack.Group, original *channel) *channel {
 		ch.latestTs = g.Latest.Timestamp
 	}
 
-	if original != nil && !original.IsNil() && ch.lastReadTs == "" {
-		ch.lastReadTs = original.lastReadTs
-		ch.latestTs = original.latestTs
-		ch.unread = original.unread
+	if original != nil && !original.IsNil() {
+		ch.inherit(&original.entity)
 	}
 
 	return ch
 }
 
-func slackUserToUser(u *slack.User, original *user) *user {
-	usr := &user{User: u}
+// isMPIM reports whether the group with the given name is a multi-party
+// im, slack lists these as groups named mpdm-<user>--<user>-1.
+func isMPIM(name string) bool {
+	return strings.HasPrefix(name, "mpdm-")
+}
+
+func slackGroupToMPIM(g *slack.Group, name string, original *mpim) *mpim {
+	m := &mpim{
+		id:      g.ID,
+		name:    name,
+		members: g.Members,
+		isOpen:  g.IsOpen,
+
+		entity: entity{
+			lastReadTs: g.LastRead,
+			unread:     g.UnreadCount,
+		},
+	}
+
+	if m.members == nil {
+		m.members = []string{}
+	}
+
+	if g.Latest != nil {
+		m.latestTs = g.Latest.Timestamp
+	}
+
+	if original != nil && !original.IsNil() {
+		m.inherit(&original.entity)
+	}
+
+	return m
+}
+
+func slackUserToUser(u *User, original *user) *user {
+	usr := &user{User: &u.User, statusExpiration: u.StatusExpiration}
 
-	if original != nil && !original.IsNil() && usr.lastReadTs == "" {
-		usr.lastReadTs = original.lastReadTs
-		usr.latestTs = original.latestTs
-		usr.unread = original.unread
+	if original != nil && !original.IsNil() {
+		usr.inherit(&original.entity)
+		usr.dnd = original.dndStatus()
+		// user_change events do not include the presence.
+		if usr.Presence == "" {
+			usr.Presence = original.presence()
+		}
 	}
 
 	return usr
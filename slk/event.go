@@ -1,13 +1,50 @@
 package slk
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/nlopes/slack"
 )
 
+// unmappedEvents maps the events nlopes/slack has no type for to the type
+// they are decoded into.
+var unmappedEvents = map[string]interface{}{
+	"member_joined_channel":   MemberChannelEvent{},
+	"member_left_channel":     MemberChannelEvent{},
+	"subteam_created":         SubteamEvent{},
+	"subteam_updated":         SubteamEvent{},
+	"subteam_members_changed": SubteamEvent{},
+	"subteam_self_added":      SubteamEvent{},
+	"subteam_self_removed":    SubteamEvent{},
+}
+
+// unmappedEvent decodes an event in unmappedEvents that nlopes/slack
+// emitted an UnmarshallingErrorEvent for.
+// The raw event is only available in the error, which nlopes/slack
+// formats as below.
+func unmappedEvent(e *slack.UnmarshallingErrorEvent) (slack.RTMEvent, bool) {
+	for typ, v := range unmappedEvents {
+		prefix := fmt.Sprintf("RTM Error: Received unmapped event %q: ", typ)
+		if !strings.HasPrefix(e.Error(), prefix) {
+			continue
+		}
+
+		event := reflect.New(reflect.TypeOf(v)).Interface()
+		raw := strings.TrimSpace(e.Error()[len(prefix):])
+		if err := json.Unmarshal([]byte(raw), event); err != nil {
+			return slack.RTMEvent{}, false
+		}
+
+		return slack.RTMEvent{Type: typ, Data: event}, true
+	}
+
+	return slack.RTMEvent{}, false
+}
+
 func (s *Slk) handleEvent(event slack.RTMEvent) error {
 	switch d := event.Data.(type) {
 
@@ -17,6 +54,8 @@ func (s *Slk) handleEvent(event slack.RTMEvent) error {
 		s.updateChannels(nil, nil)
 	case *slack.GroupUnarchiveEvent:
 		s.updateChannels(nil, nil)
+	case *slack.GroupRenameEvent:
+		s.updateChannels(nil, nil)
 
 	case *slack.ChannelCreatedEvent:
 		s.updateChannels(nil, nil)
@@ -26,6 +65,11 @@ func (s *Slk) handleEvent(event slack.RTMEvent) error {
 		s.updateChannels(nil, nil)
 	case *slack.ChannelDeletedEvent:
 		s.updateChannels(nil, nil)
+	case *slack.ChannelRenameEvent:
+		s.updateChannels(nil, nil)
+
+	case *slack.UserChangeEvent:
+		s.userChanged(&d.User)
 
 	case *slack.TeamJoinEvent:
 		s.updateUsers(nil)
@@ -33,24 +77,37 @@ func (s *Slk) handleEvent(event slack.RTMEvent) error {
 
 	case *slack.IMCreatedEvent:
 		s.updateIMs(nil)
+	case *slack.IMOpenEvent:
+		s.imOpened(d.Channel, true)
+	case *slack.IMCloseEvent:
+		s.imOpened(d.Channel, false)
+
+	case *slack.DNDUpdatedEvent:
+		s.dndUpdated(d.User, &d.Status)
 
 	case *slack.PresenceChangeEvent:
-		s.user(d.User).Presence = d.Presence
+		s.user(d.User).setPresence(d.Presence)
 		// TODO notice or something
 
 	case *slack.ChannelJoinedEvent:
-		channel := s.channel(d.Channel.ID)
-		channel.isMember = true
+		s.channel(d.Channel.ID).setMember(true)
 	case *slack.ChannelLeftEvent:
-		channel := s.channel(d.Channel)
-		channel.isMember = false
+		s.channel(d.Channel).setMember(false)
 
 	case *slack.GroupJoinedEvent:
-		channel := s.channel(d.Channel.ID)
-		channel.isMember = true
+		if isMPIM(d.Channel.Name) {
+			s.updateChannels(nil, nil)
+			break
+		}
+
+		s.channel(d.Channel.ID).setMember(true)
 	case *slack.GroupLeftEvent:
-		channel := s.channel(d.Channel)
-		channel.isMember = false
+		s.channel(d.Channel).setMember(false)
+
+	case *slack.GroupOpenEvent:
+		s.mpim(d.Channel).setOpen(true)
+	case *slack.GroupCloseEvent:
+		s.mpim(d.Channel).setOpen(false)
 
 	case *slack.UserTypingEvent:
 		channel := s.channel(d.Channel)
@@ -61,6 +118,10 @@ func (s *Slk) handleEvent(event slack.RTMEvent) error {
 			if !user.IsNil() {
 				channelName = "IM"
 			}
+
+			if m := s.mpim(d.Channel); !m.IsNil() {
+				channelName = m.QualifiedName()
+			}
 		}
 
 		s.out.Typing(
@@ -70,22 +131,31 @@ func (s *Slk) handleEvent(event slack.RTMEvent) error {
 		)
 	case *slack.MessageEvent:
 		switch d.SubType {
+		case "message_changed":
+			m := slack.Message(*d)
+			s.edit(&m)
+			return nil
+
+		case "message_deleted":
+			m := slack.Message(*d)
+			s.delete(&m)
+			return nil
+
 		case "channel_join":
 			fallthrough
 		case "group_join":
-			ch := s.channel(d.Channel)
-			ch.members = append(ch.members, d.User)
+			s.channel(d.Channel).addMember(d.User)
 
 		case "channel_leave":
 			fallthrough
 		case "group_leave":
-			ch := s.channel(d.Channel)
-			for i := range ch.members {
-				if ch.members[i] == d.User {
-					ch.members = append(ch.members[:i], ch.members[i+1:]...)
-					break
-				}
-			}
+			s.channel(d.Channel).removeMember(d.User)
+
+		case "channel_topic", "group_topic":
+			s.channel(d.Channel).setTopic(d.Topic)
+
+		case "channel_purpose", "group_purpose":
+			s.channel(d.Channel).setPurpose(d.Purpose)
 		}
 
 		m := slack.Message(*d)
@@ -97,6 +167,12 @@ func (s *Slk) handleEvent(event slack.RTMEvent) error {
 	case *slack.ReactionRemovedEvent:
 		s.reaction(d)
 
+	case *slack.PinAddedEvent:
+		s.pinned(d)
+
+	case *slack.PinRemovedEvent:
+		s.pinned(d)
+
 	case *slack.FileSharedEvent:
 		// TODO ignorable? slack.MessageEvent seems to suffice
 		s.out.Debug(d.Type, fmt.Sprintf("%+v", d.File))
@@ -130,8 +206,16 @@ func (s *Slk) handleEvent(event slack.RTMEvent) error {
 		case "emoji_use":
 			return nil
 		}
-		// TODO lookup which preferences are relevant to slek.
-		s.out.Debug(event.Type, d.Name, string(d.Value))
+
+		ok, err := s.prefChanged(d.Name, d.Value)
+		if err != nil {
+			s.out.Debug(event.Type, d.Name, err.Error())
+			return nil
+		}
+
+		if !ok {
+			s.out.Debug(event.Type, d.Name, string(d.Value))
+		}
 
 	case *slack.DisconnectedEvent:
 		if !d.Intentional {
@@ -144,6 +228,26 @@ func (s *Slk) handleEvent(event slack.RTMEvent) error {
 	case *slack.HelloEvent:
 		s.out.Notice("Slack: hello!")
 
+	case *MemberChannelEvent:
+		if d.Type == "member_joined_channel" {
+			s.channel(d.Channel).addMember(d.User)
+			break
+		}
+
+		s.channel(d.Channel).removeMember(d.User)
+
+	case *SubteamEvent:
+		if err := s.updateUserGroups(nil); err != nil {
+			s.out.Warn(err.Error())
+		}
+
+	case *slack.UnmarshallingErrorEvent:
+		if e, ok := unmappedEvent(d); ok {
+			return s.handleEvent(e)
+		}
+
+		s.out.Debug(event.Type, d.Error())
+
 	case *slack.FilePublicEvent:
 		// TODO ignorable? slack.MessageEvent seems to suffice
 		s.out.Debug(d.Type, fmt.Sprintf("%+v", d.File))
@@ -0,0 +1,100 @@
+package slk_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frizinak/slek/slk"
+)
+
+func TestUnmappedEvents(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	// Not mapped by nlopes/slack.
+	srv.AddUserGroup("S1", "devs", "U1")
+	srv.Send(map[string]interface{}{"type": "subteam_created"})
+	for i := 0; i < 100 && len(srv.Calls("usergroups.list")) < 2; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if n := len(srv.Calls("usergroups.list")); n != 2 {
+		t.Errorf("expected user groups to be refetched, got %d calls", n)
+	}
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	members := func() []string {
+		out.Reset()
+		s.Members(c, false)
+		l := out.Wait("List", 1, wait)
+		if len(l) != 1 {
+			t.Fatalf("members: %+v", out.Records(""))
+		}
+
+		names := make([]string, 0, len(l[0].Items)-1)
+		for _, item := range l[0].Items[1:] {
+			names = append(names, item.Value)
+		}
+
+		return names
+	}
+
+	if m := members(); len(m) != 2 {
+		t.Fatalf("members: %v", m)
+	}
+
+	srv.Send(
+		map[string]interface{}{
+			"type":    "member_left_channel",
+			"channel": "C1",
+			"user":    "U1",
+		},
+	)
+
+	m := members()
+	for i := 0; i < 100 && len(m) != 1; i++ {
+		time.Sleep(time.Millisecond * 10)
+		m = members()
+	}
+
+	if len(m) != 1 || m[0] != "me" {
+		t.Errorf("member_left_channel: %v", m)
+	}
+
+	out.Reset()
+	srv.Send(map[string]interface{}{"type": "something_new", "x": 1})
+	var found bool
+	for i := 0; i < 100 && !found; i++ {
+		for _, r := range out.Records("Debug") {
+			if strings.Contains(r.Text, "something_new") {
+				found = true
+			}
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if !found {
+		t.Errorf("unknown event not logged: %+v", out.Records("Debug"))
+	}
+
+	bob := entity(t, s, slk.TypeUser, "bob")
+	if err := s.Typing(bob); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100 && len(srv.Calls("rtm.typing")) == 0; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	calls := srv.Calls("rtm.typing")
+	if len(calls) != 1 || calls[0].Values.Get("channel") != "D1" {
+		t.Errorf("typing: %+v", calls)
+	}
+
+	if w := out.Records("Warn"); len(w) != 0 {
+		t.Errorf("warnings: %+v", w)
+	}
+}
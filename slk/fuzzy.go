@@ -12,6 +12,12 @@ func (a lenStr) Len() int           { return len(a) }
 func (a lenStr) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a lenStr) Less(i, j int) bool { return len(a[i]) < len(a[j]) }
 
+func fuzzyStrings(query string, targets []string) []string {
+	raw := lenStr(fuzzy.Find(query, targets))
+	sort.Sort(raw)
+	return raw
+}
+
 func fuzzySearch(query string, lookup map[string]Entity) []Entity {
 	targets := make([]string, 0, len(lookup))
 	for i := range lookup {
@@ -0,0 +1,144 @@
+package slk
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// splitWords splits a comma separated list of words as used by slack's
+// highlight_words preference.
+func splitWords(list string) []string {
+	words := make([]string, 0)
+	for _, w := range strings.Split(list, ",") {
+		if w = strings.TrimSpace(w); w != "" {
+			words = append(words, w)
+		}
+	}
+
+	return words
+}
+
+// nonWord matches a rune that is not part of a word, \b only considers
+// ascii.
+const nonWord = `[^\p{L}\p{N}_]`
+
+// reNoHighlight matches code, links and urls which should not contain
+// highlight words.
+var reNoHighlight = regexp.MustCompile(
+	"(?s)```.*?```|`[^`]+`|<[^>]*>|\\b[a-zA-Z][a-zA-Z0-9+.-]*://\\S+",
+)
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// highlightRegexp returns a case insensitive regexp that matches any of
+// the given words as a whole word or nil if there are none.
+// Each alternative captures the word without its surrounding runes in a
+// single group, see HighlightIndex.
+func highlightRegexp(words []string) *regexp.Regexp {
+	exprs := make([]string, 0, len(words))
+	for _, w := range words {
+		if w == "" {
+			continue
+		}
+
+		expr := "(" + regexp.QuoteMeta(w) + ")"
+		// Boundaries only make sense next to a word character.
+		if r, _ := utf8.DecodeRuneInString(w); isWordRune(r) {
+			expr = "(?:^|" + nonWord + ")" + expr
+		}
+		if r, _ := utf8.DecodeLastRuneInString(w); isWordRune(r) {
+			expr += "(?:" + nonWord + "|$)"
+		}
+
+		exprs = append(exprs, expr)
+	}
+
+	if len(exprs) == 0 {
+		return nil
+	}
+
+	return regexp.MustCompile("(?i)" + strings.Join(exprs, "|"))
+}
+
+// HighlightIndex returns the start and end offsets of all words in text
+// the regexp passed to Output.Highlight matches, code, links and urls are
+// skipped.
+func HighlightIndex(re *regexp.Regexp, text string) [][]int {
+	if re == nil {
+		return nil
+	}
+
+	skip := reNoHighlight.FindAllStringIndex(text, -1)
+	matches := make([][]int, 0)
+	for pos := 0; pos < len(text); {
+		m := re.FindStringSubmatchIndex(text[pos:])
+		if m == nil {
+			break
+		}
+
+		start, end := m[0], m[1]
+		for i := 2; i < len(m); i += 2 {
+			if m[i] >= 0 {
+				start, end = m[i], m[i+1]
+				break
+			}
+		}
+
+		start, end = start+pos, end+pos
+		// Continue at the end of the word, the rune following it
+		// might precede the next one.
+		if end > pos {
+			pos = end
+		} else {
+			_, n := utf8.DecodeRuneInString(text[pos:])
+			pos += n
+		}
+
+		if end == start || overlaps(skip, start, end) {
+			continue
+		}
+
+		matches = append(matches, []int{start, end})
+	}
+
+	return matches
+}
+
+// overlaps reports whether start-end overlaps any of the given ranges.
+func overlaps(ranges [][]int, start, end int) bool {
+	for _, r := range ranges {
+		if r[0] < end && start < r[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updateHighlight combines our own and slack's highlight words and passes
+// the result to the Output.
+func (s *Slk) updateHighlight() {
+	s.mutex.Lock()
+	words := make([]string, 0, len(s.highlightWords)+len(s.prefs.highlight))
+	words = append(words, s.highlightWords...)
+	words = append(words, s.prefs.highlight...)
+	re := highlightRegexp(words)
+	s.highlight = re
+	s.mutex.Unlock()
+
+	s.out.Highlight(re)
+}
+
+// highlighted reports whether text contains any of our highlight words
+// outside of code and links.
+func (s *Slk) highlighted(text string) bool {
+	s.mutex.RLock()
+	re := s.highlight
+	s.mutex.RUnlock()
+
+	return len(HighlightIndex(re, text)) != 0
+}
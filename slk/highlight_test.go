@@ -0,0 +1,51 @@
+package slk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		words []string
+		text  string
+		exp   []string
+	}{
+		{[]string{"foo"}, "foo", []string{"foo"}},
+		{[]string{"foo"}, "a Foo, b foo.", []string{"Foo", "foo"}},
+		{[]string{"foo"}, "foobar barfoo foo_", nil},
+		{[]string{"foo", "bar"}, "foo bar", []string{"foo", "bar"}},
+		{[]string{"café"}, "café", []string{"café"}},
+		{[]string{"café"}, "un café noir", []string{"café"}},
+		{[]string{"café"}, "cafés décafé", nil},
+		{[]string{"über"}, "Über alles", []string{"Über"}},
+		{[]string{"日本"}, "こんにちは 日本 です", []string{"日本"}},
+		{[]string{"++"}, "c++ and ++x", []string{"++", "++"}},
+		{[]string{"!!"}, "what!!!", []string{"!!"}},
+		{[]string{"c++"}, "c++, c++x", []string{"c++", "c++"}},
+		{[]string{"foo"}, "`foo` ```\nfoo\n``` foo", []string{"foo"}},
+		{[]string{"foo"}, "<http://foo.com|foo> http://foo.com foo", []string{"foo"}},
+		{[]string{"foo"}, "<@U1|foo> foo", []string{"foo"}},
+	}
+
+	for _, test := range tests {
+		var got []string
+		for _, m := range HighlightIndex(highlightRegexp(test.words), test.text) {
+			got = append(got, test.text[m[0]:m[1]])
+		}
+
+		if !reflect.DeepEqual(got, test.exp) {
+			t.Errorf(
+				"%q in %q: expected %q got %q",
+				test.words,
+				test.text,
+				test.exp,
+				got,
+			)
+		}
+	}
+
+	if re := highlightRegexp([]string{"", ""}); re != nil {
+		t.Errorf("expected no regexp, got %s", re)
+	}
+}
@@ -8,13 +8,80 @@ import (
 	"github.com/nlopes/slack"
 )
 
+// historyPageSize is the amount of messages fetched per page when paging
+// through history.
+const historyPageSize = 200
+
+// historyPages fetches the messages after p.Oldest up to p.Latest (or the
+// newest message), newest first.
+//
+// Slack returns the newest messages of the requested range, so we page
+// back from p.Latest until p.Oldest is reached.
+// Unless amount is 0 only the oldest amount messages are kept and unless
+// maxPages is 0 at most maxPages are fetched, complete reports whether
+// p.Oldest was reached.
+func (s *Slk) historyPages(
+	e Entity,
+	p slack.HistoryParameters,
+	amount int,
+	maxPages int,
+) (msgs []slack.Message, complete bool, err error) {
+	msgs = make([]slack.Message, 0, amount)
+	for page := 0; maxPages == 0 || page < maxPages; page++ {
+		var hist *slack.History
+		hist, err = s.historyPage(e, p)
+		if err != nil {
+			return
+		}
+
+		msgs = append(msgs, hist.Messages...)
+		if amount != 0 && len(msgs) > amount {
+			msgs = msgs[len(msgs)-amount:]
+		}
+
+		if !hist.HasMore || len(hist.Messages) == 0 {
+			complete = true
+			return
+		}
+
+		p.Latest = hist.Messages[len(hist.Messages)-1].Timestamp
+		p.Inclusive = false
+	}
+
+	return
+}
+
 func (s *Slk) history(
 	e Entity,
 	p slack.HistoryParameters,
 	newSection bool,
 ) (latest string, done bool, err error) {
-	var hist *slack.History
+	hist, err := s.historyPage(e, p)
+	if err != nil {
+		return
+	}
+
+	var l float64
+	latest, l = s.renderHistory(e, hist.Messages, newSection)
+
+	// Slack be weird, history of an IM has no hist.Latest value.
+	if hist.Latest == "" && hist.HasMore {
+		return
+	}
+
+	histLatest, _ := strconv.ParseFloat(hist.Latest, 64)
+	if latest == hist.Latest || l >= histLatest {
+		done = true
+	}
+
+	return
+}
 
+// historyPage fetches a single page of history, newest first.
+func (s *Slk) historyPage(
+	e Entity,
+	p slack.HistoryParameters,
+) (hist *slack.History, err error) {
 	switch e.Type() {
 	case TypeChannel:
 		if e.(*channel).isChannel {
@@ -25,44 +92,41 @@ func (s *Slk) history(
 		hist, err = s.groupHistory(e.ID(), p)
 	case TypeUser:
 		hist, err = s.imHistory(e.ID(), p)
+	case TypeMPIM:
+		hist, err = s.mpimHistory(e.ID(), p)
 	default:
 		err = fmt.Errorf("Can not post message to type %s", e.Type())
 	}
 
-	if err != nil {
-		return
-	}
+	return
+}
 
-	l := 0.0
+// renderHistory writes msgs (newest first) to the Output, oldest first,
+// and returns the timestamp of the latest one.
+func (s *Slk) renderHistory(
+	e Entity,
+	msgs []slack.Message,
+	newSection bool,
+) (latest string, l float64) {
 	first := true
-	for i := len(hist.Messages) - 1; i >= 0; i-- {
-		if hist.Messages[i].Channel == "" {
-			hist.Messages[i].Channel = e.ID()
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Channel == "" {
+			msgs[i].Channel = e.ID()
 			if e.Type() == TypeUser {
-				hist.Messages[i].Channel = s.imByUser(e.ID()).ID
+				msgs[i].Channel = s.imByUser(e.ID()).ID
 			}
 		}
 
-		s.msg(&hist.Messages[i], newSection && first, false, false)
+		s.msg(&msgs[i], newSection && first, false, false)
 		first = false
 
-		ts, _ := strconv.ParseFloat(hist.Messages[i].Timestamp, 64)
+		ts, _ := strconv.ParseFloat(msgs[i].Timestamp, 64)
 		if ts > l {
 			l = ts
-			latest = hist.Messages[i].Timestamp
+			latest = msgs[i].Timestamp
 		}
 	}
 
-	// Slack be weird, history of an IM has no hist.Latest value.
-	if hist.Latest == "" && hist.HasMore {
-		return
-	}
-
-	histLatest, _ := strconv.ParseFloat(hist.Latest, 64)
-	if latest == hist.Latest || l >= histLatest {
-		done = true
-	}
-
 	return
 }
 
@@ -70,15 +134,27 @@ func (s *Slk) imHistory(
 	user string,
 	p slack.HistoryParameters,
 ) (*slack.History, error) {
-	im := s.imByUser(user)
-
-	if im == nilIM {
-		return nil, errors.New("No such user...")
+	im, err := s.openIM(user)
+	if err != nil {
+		return nil, err
 	}
 
 	return s.c.GetIMHistory(im.ID, p)
 }
 
+func (s *Slk) mpimHistory(
+	id string,
+	p slack.HistoryParameters,
+) (*slack.History, error) {
+	m := s.mpim(id)
+
+	if m.IsNil() {
+		return nil, errors.New("No such mpim...")
+	}
+
+	return s.c.GetMPIMHistory(m.ID(), p)
+}
+
 func (s *Slk) channelHistory(
 	ch string,
 	p slack.HistoryParameters,
@@ -0,0 +1,117 @@
+package slk_test
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/frizinak/slek/slk"
+	"github.com/frizinak/slek/slk/slktest"
+)
+
+func TestUnread(t *testing.T) {
+	srv := slktest.NewServer("U0", "me")
+	defer srv.Close()
+	srv.AddUser("U1", "bob")
+	srv.AddChannel("C1", "general", true, "U0", "U1")
+	srv.AddChannel("C2", "random", true, "U0", "U1")
+	srv.SetLastRead("C2", srv.AddMessage("C2", "U1", "read"))
+
+	out := slktest.NewOutput()
+	s := slk.NewSlk(slktest.Token, "15:04", out)
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	go s.Run()
+	defer s.Quit()
+
+	s.Switch(entity(t, s, slk.TypeChannel, "general"))
+	out.Reset()
+
+	// More unread messages than fit in a single history page.
+	const n = 450
+	for i := 0; i < n; i++ {
+		srv.SendMessage("C2", "U1", fmt.Sprintf("m%d", i))
+	}
+
+	c := entity(t, s, slk.TypeChannel, "random")
+	for i := 0; i < 200 && c.UnreadCount() != n; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if c.UnreadCount() != n {
+		t.Fatalf("unread: %d", c.UnreadCount())
+	}
+
+	if err := s.Switch(c); err != nil {
+		t.Fatal(err)
+	}
+
+	m := out.Records("Msg")
+	if len(m) != n {
+		t.Fatalf("expected %d messages, got %d", n, len(m))
+	}
+
+	for i := range m {
+		if m[i].Text != fmt.Sprintf("m%d", i) {
+			t.Fatalf("message %d: %s", i, m[i].Text)
+		}
+	}
+}
+
+func TestHistorySummary(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	srv.Handle(
+		"channels.history",
+		func(v url.Values) map[string]interface{} {
+			return map[string]interface{}{
+				"messages": []map[string]interface{}{
+					{
+						"type": "message",
+						"user": "U1",
+						"text": "file",
+						"ts":   "1500000000.000002",
+						"file": map[string]interface{}{
+							"id":             "F1",
+							"comments_count": 1,
+						},
+					},
+					{
+						"type": "message",
+						"user": "U1",
+						"text": "hi",
+						"ts":   "1500000000.000001",
+						"reactions": []map[string]interface{}{
+							{"name": "+1", "count": 3},
+							{"name": "eyes", "count": 1},
+						},
+						"reply_count": 4,
+					},
+				},
+			}
+		},
+	)
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	s.Switch(c)
+	out.Reset()
+	s.History(c, 2)
+
+	m := out.Wait("Msg", 2, wait)
+	if len(m) != 2 {
+		t.Fatalf("history: %+v", out.Records(""))
+	}
+
+	if m[0].Text != "hi\n:+1: 3  :eyes: 1  — 4 replies" {
+		t.Errorf("summary: %q", m[0].Text)
+	}
+
+	if m[1].Text != "file\n1 comment" {
+		t.Errorf("summary: %q", m[1].Text)
+	}
+}
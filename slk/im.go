@@ -0,0 +1,67 @@
+package slk
+
+import (
+	"fmt"
+
+	"github.com/nlopes/slack"
+)
+
+// openIM returns the im with the given user, opening it if we never
+// messaged them before.
+func (s *Slk) openIM(userID string) (*slack.IM, error) {
+	if im := s.imByUser(userID); im != nilIM {
+		return im, nil
+	}
+
+	if s.user(userID).IsNil() {
+		return nil, fmt.Errorf("No such user %s", userID)
+	}
+
+	_, _, id, err := s.c.OpenIMChannel(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	im := &slack.IM{IsIM: true, User: userID}
+	im.ID = id
+	im.IsOpen = true
+	s.setIM(im)
+	return im, nil
+}
+
+// imOpened handles an im_open or im_close event.
+func (s *Slk) imOpened(id string, open bool) {
+	original := s.im(id)
+	if original == nilIM {
+		if err := s.updateIMs(nil); err != nil {
+			s.out.Warn(err.Error())
+		}
+
+		return
+	}
+
+	im := *original
+	im.IsOpen = open
+	s.setIM(&im)
+}
+
+// setIM adds or replaces the given im.
+func (s *Slk) setIM(im *slack.IM) {
+	s.updateRegistry(func(reg *registry) {
+		ims := make(map[string]*slack.IM, len(reg.ims)+1)
+		imsByUser := make(map[string]*slack.IM, len(reg.imsByUser)+1)
+		for id := range reg.ims {
+			ims[id] = reg.ims[id]
+		}
+
+		for user := range reg.imsByUser {
+			imsByUser[user] = reg.imsByUser[user]
+		}
+
+		ims[im.ID] = im
+		imsByUser[im.User] = im
+
+		reg.ims = ims
+		reg.imsByUser = imsByUser
+	})
+}
@@ -0,0 +1,83 @@
+package slk_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/frizinak/slek/slk"
+)
+
+func TestOpenIM(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	srv.AddUser("U5", "carol")
+	srv.Send(
+		map[string]interface{}{
+			"type": "team_join",
+			"user": map[string]string{"id": "U5", "name": "carol"},
+		},
+	)
+	for i := 0; i < 100 && len(s.Fuzzy(slk.TypeUser, "carol")) == 0; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	carol := entity(t, s, slk.TypeUser, "carol")
+	if len(s.IMs()) != 1 {
+		t.Fatalf("ims: %v", s.IMs())
+	}
+
+	if err := s.History(carol, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Post(carol, "hi"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Uploads(carol); err != nil {
+		t.Fatal(err)
+	}
+
+	// The im is opened once and used for everything after.
+	if o := srv.Calls("im.open"); len(o) != 1 || o[0].Values.Get("user") != "U5" {
+		t.Fatalf("im.open: %+v", o)
+	}
+
+	if len(s.IMs()) != 2 {
+		t.Fatalf("ims: %v", s.IMs())
+	}
+
+	id := srv.Calls("files.list")[0].Values.Get("channel")
+	for _, m := range []string{"im.history", "chat.postMessage"} {
+		c := srv.Calls(m)
+		if len(c) != 1 || c[0].Values.Get("channel") != id {
+			t.Errorf("%s: %+v", m, c)
+		}
+	}
+
+	imEvent := func(typ string, n int) {
+		srv.Send(
+			map[string]interface{}{
+				"type":    typ,
+				"channel": id,
+				"user":    "U0",
+			},
+		)
+		for i := 0; i < 100 && len(s.IMs()) != n; i++ {
+			time.Sleep(time.Millisecond * 10)
+		}
+
+		if len(s.IMs()) != n {
+			t.Errorf("%s: %v", typ, s.IMs())
+		}
+	}
+
+	imEvent("im_close", 1)
+	imEvent("im_open", 2)
+
+	if w := out.Records("Warn"); len(w) != 0 {
+		t.Errorf("warnings: %+v", w)
+	}
+}
@@ -19,13 +19,19 @@ func (s *Slk) reaction(r interface{}) {
 		userID = reaction.User
 		channelID = reaction.Item.Channel
 		item = reaction.Reaction
-		timestamp = reaction.Item.Timestamp
+		timestamp = reaction.EventTimestamp
+		if timestamp == "" {
+			timestamp = reaction.Item.Timestamp
+		}
 	case *slack.ReactionRemovedEvent:
 		sign = "[-]"
 		userID = reaction.User
 		channelID = reaction.Item.Channel
 		item = reaction.Reaction
-		timestamp = reaction.Item.Timestamp
+		timestamp = reaction.EventTimestamp
+		if timestamp == "" {
+			timestamp = reaction.Item.Timestamp
+		}
 	default:
 		s.out.Warn("Not a reaction event")
 		return
@@ -35,8 +41,12 @@ func (s *Slk) reaction(r interface{}) {
 	channel := s.channel(channelID)
 	entity = channel
 
-	if channel.IsNil() || !channel.isMember {
-		entity = s.user(s.im(channelID).User)
+	if channel.IsNil() || !channel.IsActive() {
+		entity = s.mpim(channelID)
+		if entity.IsNil() {
+			entity = s.user(s.im(channelID).User)
+		}
+
 		if entity.IsNil() {
 			return
 		}
@@ -45,6 +55,7 @@ func (s *Slk) reaction(r interface{}) {
 	s.msg(
 		&slack.Message{
 			Msg: slack.Msg{
+				Type:      typeReaction,
 				Channel:   channelID,
 				User:      userID,
 				Text:      fmt.Sprintf("%s %s", sign, item),
@@ -52,11 +63,15 @@ func (s *Slk) reaction(r interface{}) {
 			},
 		},
 		false,
-		entity.Type() == TypeUser,
+		entity.Type() != TypeChannel,
 		false,
 	)
 }
 
+// typeReaction marks messages created by reaction, these refer to an
+// existing message and should not be remembered.
+const typeReaction = "slk_reaction"
+
 func (s *Slk) msg(m *slack.Message, newSection, notify, isNew bool) {
 	if m.Hidden {
 		// TODO we sure 'bout that?
@@ -64,71 +79,186 @@ func (s *Slk) msg(m *slack.Message, newSection, notify, isNew bool) {
 	}
 
 	if m.User == "" && m.SubMessage != nil {
+		channel := m.Channel
 		m.Msg = *m.SubMessage
+		if m.Channel == "" {
+			m.Channel = channel
+		}
 	}
 
-	var entity Entity
-	ch := s.channel(m.Channel)
-	entity = ch
-
-	im := false
-	if ch.IsNil() {
-		user := s.user(s.im(m.Channel).User)
-		im = !user.IsNil()
-		entity = user
-	}
+	entity := s.entityByChannel(m.Channel)
+	im := entity.Type() != TypeChannel && !entity.IsNil()
 
-	if s.active == nil {
+	if s.activeEntity() == nil {
 		s.Switch(entity)
 	}
 
-	active := entity.Is(s.active)
+	active := entity.Is(s.activeEntity())
 	if isNew {
 		entity.incrementUnread()
 		entity.setLatest(m.Timestamp)
 		if active {
-			s.markRead <- entity
+			s.queueMark(entity)
 		}
 	}
 
-	username := s.user(m.User).Name()
-	if m.User == "" && m.Username != "" {
-		username = m.Username
+	username := s.msgUsername(&m.Msg)
+	text, mentions := s.msgText(&m.Msg)
+	if m.Type != typeReaction {
+		s.recent.add(
+			m.Channel,
+			&message{m.Timestamp, m.User, text, replyTo(&m.Msg)},
+		)
 	}
 
-	text, mentions := s.parseTextIncoming(
-		append([]string{m.Text},
-			s.parseAttachments(m.Attachments)...)...,
-	)
+	if isNew {
+		s.followReply(&m.Msg)
+	}
 
-	if notify && username != s.username {
-		if im {
-			if username != s.username {
-				s.out.Notify(entity.QualifiedName(), username, text, false)
-			}
-		} else {
-			for i := range mentions {
-				if mentions[i] == s.username {
-					s.out.Notify(
-						entity.QualifiedName(),
-						username,
-						text,
-						false,
-					)
-				}
+	if summary := s.parseSummary(&m.Msg); summary != "" {
+		text += "\n" + summary
+	}
+
+	self := s.Username()
+	if notify && im && isReminder(&m.Msg) {
+		// Reminders notify regardless of the NotifyPolicy.
+		s.out.Notify(entity.QualifiedName(), username, text, true)
+	} else if notify && username != self {
+		mentioned := false
+		for i := range mentions {
+			if mentions[i] == self {
+				mentioned = true
+				break
 			}
 		}
+
+		if s.shouldNotify(entity, im, mentioned) {
+			s.out.Notify(entity.QualifiedName(), username, text, false)
+		}
 	}
 
 	if !active {
 		return
 	}
 
+	var thread *Thread
+	if parent := replyTo(&m.Msg); parent != "" {
+		thread = s.thread(m.Channel, parent)
+	}
+
 	s.out.Msg(
 		entity.QualifiedName(),
 		username,
 		text,
 		ts(m.Timestamp),
+		thread,
 		newSection,
 	)
 }
+
+// replyTo returns the thread timestamp of m if it is a thread reply.
+func replyTo(m *slack.Msg) string {
+	if m.ThreadTimestamp == m.Timestamp {
+		return ""
+	}
+
+	return m.ThreadTimestamp
+}
+
+// thread describes the parent with the given timestamp.
+func (s *Slk) thread(channel, parent string) *Thread {
+	thread := &Thread{TS: ts(parent)}
+	if m := s.recent.get(channel, parent); m != nil {
+		thread.From = s.user(m.user).Name()
+		thread.Text = m.text
+	}
+
+	return thread
+}
+
+// edit handles a message_changed event.
+func (s *Slk) edit(m *slack.Message) {
+	if m.SubMessage == nil {
+		return
+	}
+
+	sub := *m.SubMessage
+	if sub.Channel == "" {
+		sub.Channel = m.Channel
+	}
+
+	entity := s.entityByChannel(sub.Channel)
+	if entity.IsNil() {
+		return
+	}
+
+	text, _ := s.msgText(&sub)
+	var prev string
+	if original := s.recent.get(sub.Channel, sub.Timestamp); original != nil {
+		prev = original.text
+		if sub.Edited == nil && prev == text {
+			return
+		}
+	}
+
+	s.recent.add(
+		sub.Channel,
+		&message{sub.Timestamp, sub.User, text, replyTo(&sub)},
+	)
+
+	if !entity.Is(s.activeEntity()) {
+		return
+	}
+
+	s.out.Edit(
+		entity.QualifiedName(),
+		s.msgUsername(&sub),
+		prev,
+		text,
+		ts(sub.Timestamp),
+	)
+}
+
+// delete handles a message_deleted event.
+func (s *Slk) delete(m *slack.Message) {
+	if m.DeletedTimestamp == "" {
+		return
+	}
+
+	entity := s.entityByChannel(m.Channel)
+	if entity.IsNil() {
+		return
+	}
+
+	var from, text string
+	if original := s.recent.remove(m.Channel, m.DeletedTimestamp); original != nil {
+		from = s.user(original.user).Name()
+		text = original.text
+	}
+
+	if !entity.Is(s.activeEntity()) {
+		return
+	}
+
+	s.out.Delete(
+		entity.QualifiedName(),
+		from,
+		text,
+		ts(m.DeletedTimestamp),
+	)
+}
+
+func (s *Slk) msgUsername(m *slack.Msg) string {
+	if m.User == "" && m.Username != "" {
+		return m.Username
+	}
+
+	return s.user(m.User).Name()
+}
+
+func (s *Slk) msgText(m *slack.Msg) (text string, mentions []string) {
+	return s.parseTextIncoming(
+		append([]string{m.Text},
+			s.parseAttachments(m.Attachments)...)...,
+	)
+}
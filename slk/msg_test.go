@@ -0,0 +1,108 @@
+package slk_test
+
+import (
+	"testing"
+
+	"github.com/frizinak/slek/slk"
+)
+
+func TestMessageChanged(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	s.Switch(c)
+	ts := srv.SendMessage("C1", "U1", "helo")
+	out.Wait("Msg", 1, wait)
+
+	changed := func(text string, edited bool) {
+		m := map[string]interface{}{
+			"type": "message",
+			"user": "U1",
+			"text": text,
+			"ts":   ts,
+		}
+		if edited {
+			m["edited"] = map[string]string{"user": "U1", "ts": srv.Ts()}
+		}
+
+		srv.Send(
+			map[string]interface{}{
+				"type":    "message",
+				"subtype": "message_changed",
+				"hidden":  true,
+				"channel": "C1",
+				"ts":      srv.Ts(),
+				"message": m,
+			},
+		)
+	}
+
+	// Unfurls change a message without editing it.
+	changed("helo", false)
+	changed("hello", true)
+	e := out.Wait("Edit", 1, wait)
+	if len(e) != 1 {
+		t.Fatalf("edit: %+v", out.Records(""))
+	}
+
+	if e[0].Channel != "#general" || e[0].From != "bob" ||
+		e[0].Prev != "helo" || e[0].Text != "hello" {
+		t.Errorf("edit: %+v", e[0])
+	}
+
+	if m := out.Records("Msg"); len(m) != 1 {
+		t.Errorf("edit rendered as a message: %+v", m)
+	}
+
+	changed("hello!", true)
+	if e = out.Wait("Edit", 2, wait); len(e) != 2 || e[1].Prev != "hello" {
+		t.Errorf("second edit: %+v", e)
+	}
+}
+
+func TestMessageDeleted(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	s.Switch(c)
+	ts := srv.SendMessage("C1", "U1", "oops")
+	im := srv.SendMessage("D1", "U1", "psst")
+	out.Wait("Msg", 1, wait)
+
+	deleted := func(channel, ts string) {
+		srv.Send(
+			map[string]interface{}{
+				"type":       "message",
+				"subtype":    "message_deleted",
+				"hidden":     true,
+				"channel":    channel,
+				"ts":         srv.Ts(),
+				"deleted_ts": ts,
+			},
+		)
+	}
+
+	// Only deletions in the active room are shown.
+	deleted("D1", im)
+	deleted("C1", ts)
+	d := out.Wait("Delete", 1, wait)
+	if len(d) != 1 {
+		t.Fatalf("delete: %+v", out.Records(""))
+	}
+
+	if d[0].Channel != "#general" || d[0].From != "bob" || d[0].Text != "oops" {
+		t.Errorf("delete: %+v", d[0])
+	}
+
+	if m := out.Records("Msg"); len(m) != 1 {
+		t.Errorf("deletion rendered as a message: %+v", m)
+	}
+
+	if _, err := s.Recent(c, 1); err == nil {
+		t.Error("deleted message is still recent")
+	}
+}
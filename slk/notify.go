@@ -0,0 +1,224 @@
+package slk
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// NotifyMode determines which messages in an entity trigger Output.Notify.
+type NotifyMode string
+
+// Notification modes, an empty mode defers to the next rule in the
+// NotifyPolicy.
+const (
+	// NotifyAll notifies on every message.
+	NotifyAll NotifyMode = "all"
+	// NotifyMentions notifies on mentions and on every message in an im
+	// or mpim.
+	NotifyMentions NotifyMode = "mentions"
+	// NotifyIMs only notifies on messages in an im or mpim.
+	NotifyIMs NotifyMode = "ims"
+	// NotifyNone never notifies.
+	NotifyNone NotifyMode = "none"
+	// NotifyMute never notifies and skips the entity in NextUnread.
+	NotifyMute NotifyMode = "mute"
+)
+
+// ParseNotifyMode validates the given mode, an empty string is allowed.
+func ParseNotifyMode(mode string) (NotifyMode, error) {
+	m := NotifyMode(mode)
+	switch m {
+	case "", NotifyAll, NotifyMentions, NotifyIMs, NotifyNone, NotifyMute:
+		return m, nil
+	}
+
+	return "", fmt.Errorf("Invalid notification mode '%s'", mode)
+}
+
+// NotifyPolicy determines when Slk notifies the Output.
+//
+// The mode for an entity is, in order of precedence:
+// its Entities override, its slack preference (if SlackPrefs is set),
+// Default, slack's global preference (if SlackPrefs is set)
+// and finally NotifyMentions.
+type NotifyPolicy struct {
+	Default NotifyMode
+	// Entities maps qualified names (#channel, @user, &mpim) to their mode.
+	Entities map[string]NotifyMode
+	// SlackPrefs takes the user's own slack notification preferences
+	// and muted channels into account.
+	SlackPrefs bool
+}
+
+// slackPrefs are the notification preferences of the slack user.
+type slackPrefs struct {
+	global    NotifyMode
+	channels  map[string]NotifyMode
+	muted     map[string]bool
+	highlight []string
+}
+
+func newSlackPrefs() *slackPrefs {
+	return &slackPrefs{
+		channels: make(map[string]NotifyMode),
+		muted:    make(map[string]bool),
+	}
+}
+
+// slackNotifyMode converts a slack desktop notification preference.
+func slackNotifyMode(pref string) NotifyMode {
+	switch pref {
+	case "everything":
+		return NotifyAll
+	case "mention":
+		return NotifyMentions
+	case "nothing":
+		return NotifyNone
+	}
+
+	return ""
+}
+
+// set updates the preference with the given name, reports whether it
+// is one we care about.
+func (p *slackPrefs) set(name, value string) (bool, error) {
+	switch name {
+	case "muted_channels":
+		p.muted = make(map[string]bool)
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				p.muted[id] = true
+			}
+		}
+
+		return true, nil
+	case "highlight_words":
+		p.highlight = splitWords(value)
+		return true, nil
+	case "all_notifications_prefs":
+		if value == "" {
+			return true, nil
+		}
+
+		prefs := &struct {
+			Global struct {
+				Desktop string `json:"global_desktop"`
+			} `json:"global"`
+			Channels map[string]struct {
+				Desktop string `json:"desktop"`
+			} `json:"channels"`
+		}{}
+
+		if err := json.Unmarshal([]byte(value), prefs); err != nil {
+			return true, err
+		}
+
+		p.global = slackNotifyMode(prefs.Global.Desktop)
+		p.channels = make(map[string]NotifyMode, len(prefs.Channels))
+		for id, c := range prefs.Channels {
+			if mode := slackNotifyMode(c.Desktop); mode != "" {
+				p.channels[id] = mode
+			}
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// updatePrefs fetches our slack preferences.
+func (s *Slk) updatePrefs() error {
+	prefs, err := s.c.GetUserPrefs()
+	if err != nil {
+		return err
+	}
+
+	p := newSlackPrefs()
+	for name, value := range prefs {
+		if _, err := p.set(name, value); err != nil {
+			return err
+		}
+	}
+
+	s.mutex.Lock()
+	s.prefs = p
+	s.mutex.Unlock()
+
+	s.updateHighlight()
+	return nil
+}
+
+// prefChanged updates the given slack preference, reports whether it is
+// relevant to slk.
+func (s *Slk) prefChanged(name string, raw json.RawMessage) (bool, error) {
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return false, nil
+	}
+
+	s.mutex.Lock()
+	p := *s.prefs
+	ok, err := p.set(name, value)
+	if ok && err == nil {
+		s.prefs = &p
+	}
+	s.mutex.Unlock()
+
+	if ok && err == nil && name == "highlight_words" {
+		s.updateHighlight()
+	}
+
+	return ok, err
+}
+
+// notifyMode returns the NotifyMode for the given entity.
+func (s *Slk) notifyMode(e Entity) NotifyMode {
+	// Slack preferences are keyed by channel id.
+	id := e.ID()
+	if e.Type() == TypeUser {
+		id = s.imByUser(id).ID
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if mode := s.policy.Entities[e.QualifiedName()]; mode != "" {
+		return mode
+	}
+
+	if s.policy.SlackPrefs {
+		if s.prefs.muted[id] {
+			return NotifyMute
+		}
+
+		if mode := s.prefs.channels[id]; mode != "" {
+			return mode
+		}
+	}
+
+	if s.policy.Default != "" {
+		return s.policy.Default
+	}
+
+	if s.policy.SlackPrefs && s.prefs.global != "" {
+		return s.prefs.global
+	}
+
+	return NotifyMentions
+}
+
+// shouldNotify reports whether a message in e should notify the Output.
+func (s *Slk) shouldNotify(e Entity, im, mentioned bool) bool {
+	switch s.notifyMode(e) {
+	case NotifyAll:
+		return true
+	case NotifyMentions:
+		return im || mentioned
+	case NotifyIMs:
+		return im
+	}
+
+	return false
+}
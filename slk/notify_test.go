@@ -0,0 +1,43 @@
+package slk
+
+import "testing"
+
+func TestNotifyMode(t *testing.T) {
+	c := &channel{id: "C1", name: "general", isChannel: true}
+
+	tests := []struct {
+		entity  NotifyMode
+		channel NotifyMode
+		muted   bool
+		def     NotifyMode
+		global  NotifyMode
+		prefs   bool
+		exp     NotifyMode
+	}{
+		{"", "", false, "", "", true, NotifyMentions},
+		{"", "", false, "", NotifyAll, true, NotifyAll},
+		{"", "", false, "", NotifyAll, false, NotifyMentions},
+		{"", "", false, NotifyIMs, NotifyAll, true, NotifyIMs},
+		{"", NotifyNone, false, NotifyIMs, NotifyAll, true, NotifyNone},
+		{"", NotifyNone, false, NotifyIMs, NotifyAll, false, NotifyIMs},
+		{"", NotifyNone, true, NotifyIMs, NotifyAll, true, NotifyMute},
+		{NotifyAll, NotifyNone, true, NotifyIMs, NotifyNone, true, NotifyAll},
+	}
+
+	for i, test := range tests {
+		s := NewSlkWithAPI(nil, "", nil)
+		s.policy = NotifyPolicy{
+			Default:    test.def,
+			Entities:   map[string]NotifyMode{"#general": test.entity},
+			SlackPrefs: test.prefs,
+		}
+
+		s.prefs.global = test.global
+		s.prefs.channels["C1"] = test.channel
+		s.prefs.muted["C1"] = test.muted
+
+		if mode := s.notifyMode(c); mode != test.exp {
+			t.Errorf("%d: expected %s got %s", i, test.exp, mode)
+		}
+	}
+}
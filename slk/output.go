@@ -1,6 +1,7 @@
 package slk
 
 import (
+	"regexp"
 	"time"
 	"unicode"
 	"unicode/utf8"
@@ -62,10 +63,20 @@ func runeToLower(r rune) rune {
 	return r
 }
 
+// Thread describes the parent message of a thread reply.
+type Thread struct {
+	TS   time.Time
+	From string
+	// Text is empty if the parent message is unknown.
+	Text string
+}
+
 // Output allows for different implementations of the slk ui.
 type Output interface {
 	// Notify should do something that stands out relative to the rest of
 	// the methods and represens a message event.
+	// Forced notifications (e.g.: reminders) should not be rate limited
+	// or suppressed by DND.
 	Notify(channel, from, text string, force bool)
 
 	// Info will be called when a 'positive' event occurs.
@@ -75,7 +86,21 @@ type Output interface {
 	// Warn will be called when an errors occurs.
 	Warn(msg string)
 	// Msg should render a slack message.
-	Msg(channel, from, msg string, ts time.Time, newSection bool)
+	// thread is nil unless the message is a thread reply.
+	Msg(
+		channel,
+		from,
+		msg string,
+		ts time.Time,
+		thread *Thread,
+		newSection bool,
+	)
+	// Edit should update a message previously rendered by Msg, identified
+	// by channel and ts. prev is the original text or empty if unknown.
+	Edit(channel, from, prev, msg string, ts time.Time)
+	// Delete should mark a message previously rendered by Msg, identified
+	// by channel and ts, as deleted. from and msg are empty if unknown.
+	Delete(channel, from, msg string, ts time.Time)
 	// Debug will be called with dev info.
 	Debug(msg ...string)
 	// Typing should notify the user of another user's typing status.
@@ -84,4 +109,12 @@ type Output interface {
 	File(channel, from, title, url string)
 	// List should render the given list.
 	List(items ListItems, reverse bool)
+	// DND will be called when our own do not disturb period changes,
+	// unforced Notify calls between from and until should not disturb
+	// the user.
+	// A zero from means since forever, a zero until means DND is off.
+	DND(from, until time.Time)
+	// Highlight will be called when our highlight words change, re is
+	// nil if there are none, use HighlightIndex to find them in text.
+	Highlight(re *regexp.Regexp)
 }
@@ -1,6 +1,7 @@
 package slk
 
 import (
+	"fmt"
 	"html"
 	"regexp"
 	"strconv"
@@ -12,9 +13,14 @@ import (
 
 var (
 	reEntity     = regexp.MustCompile(`<(#|@|!)([^>]+)>`)
-	reEntityRepl = regexp.MustCompile(`<(#|@|!)([^>|]+)(?:|[^>]+)?>`)
+	reEntityRepl = regexp.MustCompile(`<(#|@|!)([^>|]+)(?:\|([^>]+))?>`)
+	reMention    = regexp.MustCompile(`(^|\s)@([a-z0-9._-]+)`)
+
+	escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
 )
 
+const subteamPrefix = "subteam^"
+
 func ts(ts string) (t time.Time) {
 	t = time.Unix(0, 0)
 	_s := strings.Split(ts, ".")
@@ -34,6 +40,9 @@ func ts(ts string) (t time.Time) {
 }
 
 func (s *Slk) parseTextIncoming(texts ...string) (parsed string, mentions []string) {
+	self := s.Username()
+	selfID := s.self()
+	reg := s.entities()
 	clean := make([]string, 0, len(texts))
 	for i := range texts {
 
@@ -41,7 +50,7 @@ func (s *Slk) parseTextIncoming(texts ...string) (parsed string, mentions []stri
 			html.UnescapeString(texts[i]),
 			func(str string) string {
 				m := reEntityRepl.FindStringSubmatch(str)
-				if len(m) != 3 {
+				if len(m) != 4 {
 					return str
 				}
 
@@ -58,9 +67,24 @@ func (s *Slk) parseTextIncoming(texts ...string) (parsed string, mentions []stri
 					case "channel":
 						fallthrough
 					case "here":
-						mentions = append(mentions, s.username)
+						mentions = append(mentions, self)
 						return "@" + m[2]
 					}
+
+					if strings.HasPrefix(m[2], subteamPrefix) {
+						g := reg.group(m[2][len(subteamPrefix):])
+						if g == nil && m[3] != "" {
+							return m[3]
+						} else if g == nil {
+							return str
+						}
+
+						if g.members[selfID] {
+							mentions = append(mentions, self)
+						}
+
+						return "@" + g.handle
+					}
 				}
 
 				if !entity.IsNil() {
@@ -76,6 +100,10 @@ func (s *Slk) parseTextIncoming(texts ...string) (parsed string, mentions []stri
 			continue
 		}
 
+		if s.highlighted(txt) {
+			mentions = append(mentions, self)
+		}
+
 		clean = append(clean, txt)
 	}
 
@@ -83,6 +111,30 @@ func (s *Slk) parseTextIncoming(texts ...string) (parsed string, mentions []stri
 	return
 }
 
+// parseTextOutgoing escapes text and encodes @handle mentions of user
+// groups, the result should be sent as is.
+func (s *Slk) parseTextOutgoing(text string) string {
+	reg := s.entities()
+	return reMention.ReplaceAllStringFunc(
+		escaper.Replace(text),
+		func(str string) string {
+			m := reMention.FindStringSubmatch(str)
+			g := reg.groupByHandle(m[2])
+			if g == nil {
+				return str
+			}
+
+			return fmt.Sprintf(
+				"%s<!%s%s|@%s>",
+				m[1],
+				subteamPrefix,
+				g.id,
+				g.handle,
+			)
+		},
+	)
+}
+
 func (s *Slk) parseAttachments(attachments []slack.Attachment) []string {
 	texts := make([]string, 0, len(attachments))
 	for i := range attachments {
@@ -121,3 +173,38 @@ func (s *Slk) parseAttachments(attachments []slack.Attachment) []string {
 
 	return texts
 }
+
+// parseSummary summarizes the reactions, thread replies and file comments
+// of a message, e.g.: ":+1: 3  :eyes: 1  — 4 replies".
+func (s *Slk) parseSummary(m *slack.Msg) string {
+	parts := make([]string, 0, 3)
+	if len(m.Reactions) != 0 {
+		reactions := make([]string, 0, len(m.Reactions))
+		for _, r := range m.Reactions {
+			reactions = append(reactions, fmt.Sprintf(":%s: %d", r.Name, r.Count))
+		}
+
+		parts = append(parts, strings.Join(reactions, "  "))
+	}
+
+	if m.ReplyCount != 0 {
+		parts = append(parts, plural(m.ReplyCount, "reply", "replies"))
+	}
+
+	if m.File != nil && m.File.CommentsCount != 0 {
+		parts = append(
+			parts,
+			plural(m.File.CommentsCount, "comment", "comments"),
+		)
+	}
+
+	return strings.Join(parts, "  — ")
+}
+
+func plural(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+
+	return fmt.Sprintf("%d %s", n, plural)
+}
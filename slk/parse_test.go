@@ -0,0 +1,31 @@
+package slk_test
+
+import (
+	"testing"
+
+	"github.com/frizinak/slek/slk"
+)
+
+func TestHighlightMention(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	s.Switch(c)
+
+	out.Reset()
+	srv.SetPref("highlight_words", "café")
+	out.Wait("Highlight", 1, wait)
+
+	srv.SendMessage("C1", "U1", "`café` ```café``` <http://café.com|café>")
+	srv.SendMessage("C1", "U1", "un café")
+	if m := out.Wait("Msg", 2, wait); len(m) != 2 {
+		t.Fatalf("msg: %+v", out.Records(""))
+	}
+
+	n := out.Wait("Notify", 1, wait)
+	if len(n) != 1 || n[0].Text != "un café" {
+		t.Errorf("notify: %+v", n)
+	}
+}
@@ -0,0 +1,135 @@
+package slk
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+// pin pins or unpins the message with the given timestamp.
+func (s *Slk) pin(e Entity, ts string, add bool) error {
+	if e.Type() != TypeChannel {
+		if add {
+			return fmt.Errorf("Can not pin messages in a %s", e.Type())
+		}
+
+		return fmt.Errorf("Can not unpin messages in a %s", e.Type())
+	}
+
+	ref := slack.NewRefToMessage(e.ID(), ts)
+	if add {
+		return s.c.AddPin(e.ID(), ref)
+	}
+
+	return s.c.RemovePin(e.ID(), ref)
+}
+
+// itemsToList converts pinned or starred items to ListItems, newest last.
+// If withChannel is true the channel of each item is included.
+func (s *Slk) itemsToList(items []slack.Item, withChannel bool) ListItems {
+	listItems := make(ListItems, 0, len(items)*2+1)
+	for i := range items {
+		var url string
+		var msg string
+		var from = nilUser
+		var timestamp time.Time
+
+		if items[i].File != nil {
+			from = s.user(items[i].File.User)
+			url = items[i].File.URLPrivate
+			timestamp = items[i].File.Timestamp.Time()
+		}
+
+		if items[i].Message != nil {
+			if from.IsNil() {
+				from = s.user(items[i].Message.User)
+			}
+			msg = items[i].Message.Text
+			timestamp = ts(items[i].Message.Timestamp)
+		}
+
+		var in string
+		if withChannel && items[i].Channel != "" {
+			in = fmt.Sprintf(
+				" in %s",
+				s.entityByChannel(items[i].Channel).QualifiedName(),
+			)
+		}
+
+		// Don't return early so we know parsing is flawed
+		// if only the username and stamp are shown without msg or url.
+
+		if msg == "" {
+			listItems = append(listItems, &ListItem{ListItemStatusNone, url})
+		} else {
+			listItems = append(listItems, &ListItem{ListItemStatusNone, msg})
+		}
+
+		listItems = append(
+			listItems,
+			&ListItem{
+				ListItemStatusNormal,
+				fmt.Sprintf(
+					"%s%s: %s",
+					from.QualifiedName(),
+					in,
+					timestamp.Format(s.timeFormat),
+				),
+			},
+		)
+
+	}
+
+	return listItems
+}
+
+// pinned handles pin_added and pin_removed events.
+func (s *Slk) pinned(p interface{}) {
+	var userID string
+	var channelID string
+	var action string
+	var item slack.Item
+
+	switch pin := p.(type) {
+	case *slack.PinAddedEvent:
+		action = "pinned"
+		userID = pin.User
+		channelID = pin.Channel
+		item = pin.Item
+	case *slack.PinRemovedEvent:
+		action = "unpinned"
+		userID = pin.User
+		channelID = pin.Channel
+		item = pin.Item
+	default:
+		s.out.Warn("Not a pin event")
+		return
+	}
+
+	entity := s.entityByChannel(channelID)
+	if entity.IsNil() || !entity.Is(s.activeEntity()) {
+		return
+	}
+
+	var what string
+	switch {
+	case item.Message != nil:
+		text, _ := s.msgText(&item.Message.Msg)
+		what = fmt.Sprintf("a message: %s", excerpt(text))
+	case item.File != nil:
+		what = fmt.Sprintf("a file: %s", item.File.Title)
+	default:
+		what = "an item"
+	}
+
+	s.out.Info(
+		fmt.Sprintf(
+			"%s %s %s in %s",
+			s.user(userID).Name(),
+			action,
+			what,
+			entity.QualifiedName(),
+		),
+	)
+}
@@ -0,0 +1,80 @@
+package slk_test
+
+import (
+	"testing"
+
+	"github.com/frizinak/slek/slk"
+)
+
+func TestPin(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	s.Switch(c)
+	srv.SendMessage("C1", "U1", "important")
+	im := srv.SendMessage("D1", "U1", "secret")
+	out.Wait("Msg", 1, wait)
+
+	ts, err := s.Recent(c, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+
+	bob := entity(t, s, slk.TypeUser, "bob")
+	if err := s.Pin(bob, im); err == nil {
+		t.Error("pinned a message in an im")
+	}
+
+	// Pins in inactive rooms are not shown.
+	srv.Send(
+		map[string]interface{}{
+			"type":       "pin_added",
+			"user":       "U1",
+			"channel_id": "D1",
+			"item": map[string]interface{}{
+				"type":    "message",
+				"channel": "D1",
+				"message": map[string]string{"text": "secret", "ts": im},
+			},
+			"event_ts": srv.Ts(),
+		},
+	)
+
+	if err := s.Pin(c, ts); err != nil {
+		t.Fatal(err)
+	}
+
+	i := out.Wait("Info", 1, wait)
+	if len(i) != 1 || i[0].Text != "me pinned a message: important in #general" {
+		t.Fatalf("pin: %+v", out.Records(""))
+	}
+
+	out.Reset()
+	s.Pins(c)
+	l := out.Wait("List", 1, wait)
+	if len(l) != 1 || len(l[0].Items) != 3 || l[0].Items[2].Value != "important" {
+		t.Fatalf("pins: %+v", out.Records(""))
+	}
+
+	if err := s.Unpin(c, ts); err != nil {
+		t.Fatal(err)
+	}
+
+	i = out.Wait("Info", 1, wait)
+	if len(i) != 1 || i[0].Text != "me unpinned a message: important in #general" {
+		t.Fatalf("unpin: %+v", out.Records(""))
+	}
+
+	s.Pins(c)
+	if l = out.Wait("List", 2, wait); len(l) != 2 || len(l[1].Items) != 1 {
+		t.Errorf("pins: %+v", l)
+	}
+
+	if err := s.Pin(c, im); err == nil {
+		t.Error("pinned a message of another room")
+	}
+}
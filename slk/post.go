@@ -7,47 +7,169 @@ import (
 	"github.com/nlopes/slack"
 )
 
-func (s *Slk) post(e Entity, msg string) error {
+// post a message to the given entity, if thread is not empty the message is
+// posted as a reply in that thread.
+func (s *Slk) post(e Entity, msg, thread string) error {
+	msg = s.parseTextOutgoing(msg)
 	switch e.Type() {
 	case TypeUser:
-		return s.postIM(e.ID(), msg)
+		return s.postIM(e.ID(), msg, thread)
 	case TypeChannel:
-		return s.postChannel(e.ID(), msg)
+		return s.postChannel(e.ID(), msg, thread)
+	case TypeMPIM:
+		return s.postMPIM(e.ID(), msg, thread)
 	}
 
 	return fmt.Errorf("Can not post message to type %s", e.Type())
 }
 
-func (s *Slk) postChannel(ch, msg string) error {
-	channel, ok := s.channels[ch]
+// channelID returns the id of the channel, group, mpim or im of the given
+// entity, opening the im if needed.
+func (s *Slk) channelID(e Entity) (string, error) {
+	switch e.Type() {
+	case TypeUser:
+		im, err := s.openIM(e.ID())
+		if err != nil {
+			return "", err
+		}
+
+		return im.ID, nil
+	case TypeChannel, TypeMPIM:
+		return e.ID(), nil
+	}
+
+	return "", fmt.Errorf("%s has no channel", e.Type())
+}
+
+// lastOwn returns the channel id of the given entity and the last message
+// we posted in it.
+func (s *Slk) lastOwn(e Entity) (string, *message, error) {
+	id, err := s.channelID(e)
+	if err != nil {
+		return "", nil, err
+	}
+
+	m := s.recent.last(id, s.self())
+	if m == nil {
+		return "", nil, fmt.Errorf(
+			"No recent message of yours in %s",
+			e.QualifiedName(),
+		)
+	}
+
+	return id, m, nil
+}
+
+// nth returns the channel id of the given entity and its n-th most
+// recent message.
+func (s *Slk) nth(e Entity, n int) (string, *message, error) {
+	id, err := s.channelID(e)
+	if err != nil {
+		return "", nil, err
+	}
+
+	m := s.recent.nth(id, n)
+	if m == nil {
+		return "", nil, fmt.Errorf(
+			"No message #%d in %s",
+			n,
+			e.QualifiedName(),
+		)
+	}
+
+	return id, m, nil
+}
+
+func (s *Slk) editLast(e Entity, edit func(string) (string, error)) error {
+	id, m, err := s.lastOwn(e)
+	if err != nil {
+		return err
+	}
+
+	msg, err := edit(m.text)
+	if err != nil {
+		return err
+	}
+
+	_, _, _, err = s.c.UpdateMessage(id, m.ts, s.parseTextOutgoing(msg))
+	return err
+}
+
+func (s *Slk) deleteLast(e Entity) error {
+	id, m, err := s.lastOwn(e)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = s.c.DeleteMessage(id, m.ts)
+	return err
+}
+
+func (s *Slk) postChannel(ch, msg, thread string) error {
+	channel, ok := s.entities().channels[ch]
 
 	if !ok {
 		return errors.New("No such channel")
 	}
 
 	p := slack.NewPostMessageParameters()
-	p.Username = s.username
+	p.Username = s.Username()
 	p.AsUser = true
 	p.LinkNames = 1
+	p.EscapeText = false
 
-	_, _, err := s.r.PostMessage(channel.ID(), msg, p)
+	p.ThreadTimestamp = thread
 
-	return err
+	return s.postMessage(channel.ID(), msg, p)
 }
 
-func (s *Slk) postIM(name string, msg string) error {
-	user, ok := s.users[name]
+func (s *Slk) postMPIM(id, msg, thread string) error {
+	m, ok := s.entities().mpims[id]
 
 	if !ok {
-		return errors.New("No such user")
+		return errors.New("No such mpim")
 	}
 
 	p := slack.NewPostMessageParameters()
-	p.Username = s.username
+	p.Username = s.Username()
 	p.AsUser = true
 	p.LinkNames = 1
+	p.EscapeText = false
 
-	_, _, err := s.r.PostMessage(user.ID(), msg, p)
+	p.ThreadTimestamp = thread
 
-	return err
+	return s.postMessage(m.ID(), msg, p)
+}
+
+func (s *Slk) postIM(name, msg, thread string) error {
+	im, err := s.openIM(name)
+	if err != nil {
+		return err
+	}
+
+	p := slack.NewPostMessageParameters()
+	p.Username = s.Username()
+	p.AsUser = true
+	p.LinkNames = 1
+	p.EscapeText = false
+
+	p.ThreadTimestamp = thread
+
+	return s.postMessage(im.ID, msg, p)
+}
+
+// postMessage posts a message and remembers its timestamp.
+func (s *Slk) postMessage(
+	channel,
+	msg string,
+	p slack.PostMessageParameters,
+) error {
+	id, ts, err := s.c.PostMessage(channel, msg, p)
+	if err != nil {
+		return err
+	}
+
+	text, _ := s.parseTextIncoming(msg)
+	s.recent.add(id, &message{ts, s.self(), text, p.ThreadTimestamp})
+	return nil
 }
@@ -0,0 +1,75 @@
+package slk_test
+
+import (
+	"testing"
+
+	"github.com/frizinak/slek/slk"
+)
+
+func TestEditOwn(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	s.Switch(c)
+	if err := s.DeleteLast(c); err == nil {
+		t.Error("deleted without a message of ours")
+	}
+
+	if err := s.Post(c, "teh typo"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only our own messages are edited.
+	srv.SendMessage("C1", "U1", "teh other")
+	out.Wait("Msg", 2, wait)
+
+	if err := s.ReplaceLast(c, "teh", "the"); err != nil {
+		t.Fatal(err)
+	}
+
+	e := out.Wait("Edit", 1, wait)
+	if len(e) != 1 || e[0].Prev != "teh typo" || e[0].Text != "the typo" {
+		t.Fatalf("replace: %+v", out.Records(""))
+	}
+
+	if err := s.ReplaceLast(c, "teh", "the"); err == nil {
+		t.Error("replaced a missing string")
+	}
+
+	if err := s.EditLast(c, "a & b"); err != nil {
+		t.Fatal(err)
+	}
+
+	e = out.Wait("Edit", 2, wait)
+	if len(e) != 2 || e[1].Prev != "the typo" || e[1].Text != "a & b" {
+		t.Fatalf("edit: %+v", out.Records(""))
+	}
+
+	calls := srv.Calls("chat.update")
+	if len(calls) != 2 || calls[1].Values.Get("text") != "a &amp; b" {
+		t.Errorf("chat.update: %+v", calls)
+	}
+
+	if err := s.DeleteLast(c); err != nil {
+		t.Fatal(err)
+	}
+
+	d := out.Wait("Delete", 1, wait)
+	if len(d) != 1 || d[0].From != "me" || d[0].Text != "a & b" {
+		t.Fatalf("delete: %+v", out.Records(""))
+	}
+
+	if err := s.DeleteLast(c); err == nil {
+		t.Error("deleted a message twice")
+	}
+
+	if n := len(srv.Calls("chat.delete")); n != 1 {
+		t.Errorf("chat.delete: %d calls", n)
+	}
+
+	if w := out.Records("Warn"); len(w) != 3 {
+		t.Errorf("warnings: %+v", w)
+	}
+}
@@ -0,0 +1,36 @@
+package slk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+// react adds or removes a reaction to the message with the given timestamp.
+func (s *Slk) react(e Entity, ts, name string, add bool) error {
+	name = strings.Trim(name, ":")
+	if name == "" {
+		return fmt.Errorf("No emoji given")
+	}
+
+	id, err := s.channelID(e)
+	if err != nil {
+		return err
+	}
+
+	ref := slack.NewRefToMessage(id, ts)
+	if add {
+		err = s.c.AddReaction(name, ref)
+	} else {
+		err = s.c.RemoveReaction(name, ref)
+	}
+
+	// Slack validates the name against both its standard and our custom
+	// emoji.
+	if err != nil && err.Error() == "invalid_name" {
+		return fmt.Errorf("No such emoji :%s:", name)
+	}
+
+	return err
+}
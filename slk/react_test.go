@@ -0,0 +1,55 @@
+package slk_test
+
+import (
+	"testing"
+
+	"github.com/frizinak/slek/slk"
+)
+
+func TestReactValidation(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	s.Switch(c)
+	ts := srv.SendMessage("C1", "U1", "hello")
+	out.Wait("Msg", 1, wait)
+
+	if err := s.AddReaction(c, ts, ":+1:"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := s.AddReaction(c, ts, "nope")
+	if err == nil || err.Error() != "No such emoji :nope:" {
+		t.Errorf("invalid emoji: %v", err)
+	}
+
+	srv.AddEmoji("nope", "https://example.com/nope.png")
+	if err := s.AddReaction(c, ts, "nope"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.RemoveReaction(c, ts, ":eyes:"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.RemoveReaction(c, ts, "nah")
+	if err == nil || err.Error() != "No such emoji :nah:" {
+		t.Errorf("invalid emoji: %v", err)
+	}
+
+	calls := srv.Calls("reactions.add")
+	if len(calls) != 3 || calls[2].Values.Get("name") != "nope" {
+		t.Errorf("reactions.add: %v", calls)
+	}
+
+	calls = srv.Calls("reactions.remove")
+	if len(calls) != 2 || calls[0].Values.Get("name") != "eyes" {
+		t.Errorf("reactions.remove: %v", calls)
+	}
+
+	if w := out.Records("Warn"); len(w) != 2 {
+		t.Errorf("warnings: %+v", w)
+	}
+}
@@ -0,0 +1,125 @@
+package slk
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// recentLimit is the maximum amount of messages remembered per channel.
+const recentLimit = 200
+
+// message is the part of a slack message Slk remembers after rendering it.
+type message struct {
+	ts     string
+	user   string
+	text   string
+	thread string
+}
+
+// recent remembers the most recent messages of each channel / im (by id)
+// so edits and deletions can refer to the original message.
+type recent struct {
+	mutex sync.Mutex
+	msgs  map[string][]*message
+}
+
+func newRecent() *recent {
+	return &recent{msgs: make(map[string][]*message)}
+}
+
+func tsFloat(ts string) float64 {
+	f, _ := strconv.ParseFloat(ts, 64)
+	return f
+}
+
+// add stores m sorted by timestamp, replacing a message with the
+// same timestamp.
+func (r *recent) add(channel string, m *message) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	msgs := r.msgs[channel]
+	f := tsFloat(m.ts)
+	i := sort.Search(len(msgs), func(i int) bool {
+		return tsFloat(msgs[i].ts) >= f
+	})
+
+	if i < len(msgs) && msgs[i].ts == m.ts {
+		msgs[i] = m
+		return
+	}
+
+	if i == 0 && len(msgs) >= recentLimit {
+		return
+	}
+
+	msgs = append(msgs, nil)
+	copy(msgs[i+1:], msgs[i:])
+	msgs[i] = m
+	if len(msgs) > recentLimit {
+		msgs = msgs[len(msgs)-recentLimit:]
+	}
+
+	r.msgs[channel] = msgs
+}
+
+// get returns the message with the given timestamp or nil.
+func (r *recent) get(channel, ts string) *message {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, m := range r.msgs[channel] {
+		if m.ts == ts {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// nth returns the n-th most recent message (1 = latest) or nil.
+func (r *recent) nth(channel string, n int) *message {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	msgs := r.msgs[channel]
+	if n < 1 || n > len(msgs) {
+		return nil
+	}
+
+	return msgs[len(msgs)-n]
+}
+
+// last returns the most recent message sent by the given user or nil.
+func (r *recent) last(channel, user string) *message {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	msgs := r.msgs[channel]
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].user == user {
+			return msgs[i]
+		}
+	}
+
+	return nil
+}
+
+// remove forgets the message with the given timestamp and returns it
+// or nil if it was unknown.
+func (r *recent) remove(channel, ts string) *message {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	msgs := r.msgs[channel]
+	for i := range msgs {
+		if msgs[i].ts == ts {
+			m := msgs[i]
+			r.msgs[channel] = append(msgs[:i:i], msgs[i+1:]...)
+			return m
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package slk
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRecent(t *testing.T) {
+	r := newRecent()
+	for _, ts := range []string{"3.0", "1.0", "10.0", "2.0"} {
+		r.add("C1", &message{ts: ts, user: "U1", text: ts})
+	}
+
+	for n, exp := range []string{"", "10.0", "3.0", "2.0", "1.0", ""} {
+		m := r.nth("C1", n)
+		if (m == nil) != (exp == "") || (m != nil && m.ts != exp) {
+			t.Errorf("nth %d: expected %q got %+v", n, exp, m)
+		}
+	}
+
+	r.add("C1", &message{ts: "2.0", user: "U0", text: "replaced"})
+	if m := r.get("C1", "2.0"); m == nil || m.text != "replaced" {
+		t.Errorf("replace: %+v", m)
+	}
+
+	if m := r.last("C1", "U0"); m == nil || m.ts != "2.0" {
+		t.Errorf("last: %+v", m)
+	}
+
+	if m := r.remove("C1", "2.0"); m == nil || r.get("C1", "2.0") != nil {
+		t.Errorf("remove: %+v", m)
+	}
+
+	if m := r.last("C1", "U0"); m != nil {
+		t.Errorf("last after remove: %+v", m)
+	}
+
+	if r.nth("C2", 1) != nil {
+		t.Error("channels are not separate")
+	}
+}
+
+func TestRecentLimit(t *testing.T) {
+	r := newRecent()
+	for i := 1; i <= recentLimit+10; i++ {
+		r.add("C1", &message{ts: fmt.Sprintf("%d.0", i)})
+	}
+
+	if m := r.nth("C1", recentLimit); m == nil || m.ts != "11.0" {
+		t.Errorf("oldest: %+v", m)
+	}
+
+	if m := r.nth("C1", recentLimit+1); m != nil {
+		t.Errorf("over the limit: %+v", m)
+	}
+
+	// Messages older than all remembered ones are dropped.
+	r.add("C1", &message{ts: "5.0"})
+	if r.get("C1", "5.0") != nil {
+		t.Error("old message added")
+	}
+
+	r.add("C1", &message{ts: "15.5"})
+	if r.get("C1", "15.5") == nil || r.get("C1", "11.0") != nil {
+		t.Error("limit not kept")
+	}
+}
@@ -0,0 +1,166 @@
+package slk
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+const (
+	slackbotID     = "USLACKBOT"
+	reminderPrefix = "Reminder: "
+	reminderFormat = "Mon 2 Jan"
+)
+
+// whenFormats are the absolute time formats parseWhen understands,
+// formats without a date refer to the first upcoming occurrence.
+var whenFormats = []struct {
+	layout string
+	dated  bool
+}{
+	{"2006-01-02T15:04", true},
+	{"2006-01-02", true},
+	{"15:04", false},
+}
+
+// parseWhen parses a duration relative to now (10m, 2h) or an absolute
+// (local) time: 15:04, 2006-01-02 or 2006-01-02T15:04.
+func parseWhen(when string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(when); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("'%s' is not in the future", when)
+		}
+
+		return now.Add(d), nil
+	}
+
+	for _, f := range whenFormats {
+		t, err := time.ParseInLocation(f.layout, when, now.Location())
+		if err != nil {
+			continue
+		}
+
+		if !f.dated {
+			y, m, d := now.Date()
+			t = time.Date(
+				y, m, d,
+				t.Hour(), t.Minute(), 0, 0,
+				now.Location(),
+			)
+
+			if !t.After(now) {
+				t = t.AddDate(0, 0, 1)
+			}
+		}
+
+		if !t.After(now) {
+			return time.Time{}, fmt.Errorf("'%s' is not in the future", when)
+		}
+
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("Can not parse time '%s'", when)
+}
+
+type byReminderTime []Reminder
+
+func (a byReminderTime) Len() int      { return len(a) }
+func (a byReminderTime) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byReminderTime) Less(i, j int) bool {
+	if (a[i].CompleteTS == 0) != (a[j].CompleteTS == 0) {
+		return a[i].CompleteTS == 0
+	}
+
+	return a[i].Time < a[j].Time
+}
+
+// isReminder reports whether m is a reminder firing.
+func isReminder(m *slack.Msg) bool {
+	return m.User == slackbotID && strings.HasPrefix(m.Text, reminderPrefix)
+}
+
+// remind creates a reminder for the given user, nil is ourselves.
+func (s *Slk) remind(u Entity, when, text string) (*Reminder, error) {
+	t, err := parseWhen(when, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var id string
+	if u != nil {
+		if u.Type() != TypeUser {
+			return nil, fmt.Errorf("Can not remind a %s", u.Type())
+		}
+
+		id = u.ID()
+	}
+
+	return s.c.AddReminder(id, text, strconv.FormatInt(t.Unix(), 10))
+}
+
+// reminders fetches all reminders, pending ones first, soonest first.
+func (s *Slk) reminders() ([]Reminder, error) {
+	reminders, err := s.c.ListReminders()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Stable(byReminderTime(reminders))
+
+	s.mutex.Lock()
+	s.lastReminders = reminders
+	s.mutex.Unlock()
+
+	return reminders, nil
+}
+
+// reminder returns the n-th (1 based) reminder of the last listing.
+func (s *Slk) reminder(n int) (*Reminder, error) {
+	s.mutex.RLock()
+	reminders := s.lastReminders
+	s.mutex.RUnlock()
+
+	if reminders == nil {
+		return nil, errors.New("List your reminders first")
+	}
+
+	if n < 1 || n > len(reminders) {
+		return nil, fmt.Errorf("No reminder #%d", n)
+	}
+
+	return &reminders[n-1], nil
+}
+
+func (s *Slk) remindersToList(reminders []Reminder) ListItems {
+	items := make(ListItems, 1, len(reminders)+1)
+	items[0] = &ListItem{ListItemStatusTitle, "Reminders"}
+	for i := range reminders {
+		r := &reminders[i]
+		status := ListItemStatusGood
+		when := "recurring"
+		if r.Time != 0 {
+			t := time.Unix(r.Time, 0)
+			when = t.Format(reminderFormat) + " " + t.Format(s.timeFormat)
+		}
+
+		if r.CompleteTS != 0 {
+			status = ListItemStatusNormal
+			when = "done"
+		}
+
+		text := fmt.Sprintf("[%d] %s: %s", i+1, when, r.Text)
+		if r.User != "" && r.User != s.self() {
+			text += " (for " + s.user(r.User).QualifiedName() + ")"
+		}
+
+		items = append(items, &ListItem{status, text})
+	}
+
+	return items
+}
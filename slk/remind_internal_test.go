@@ -0,0 +1,47 @@
+package slk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWhen(t *testing.T) {
+	now := time.Date(2017, 3, 14, 12, 30, 0, 0, time.UTC)
+	at := func(d, h, m int) time.Time {
+		return time.Date(2017, 3, d, h, m, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		when string
+		exp  time.Time
+		err  bool
+	}{
+		{"10m", at(14, 12, 40), false},
+		{"2h", at(14, 14, 30), false},
+		{"36h", at(16, 0, 30), false},
+		{"0s", time.Time{}, true},
+		{"-5m", time.Time{}, true},
+		{"14:00", at(14, 14, 0), false},
+		{"12:31", at(14, 12, 31), false},
+		{"12:30", at(15, 12, 30), false},
+		{"09:15", at(15, 9, 15), false},
+		{"2017-03-20", at(20, 0, 0), false},
+		{"2017-03-20T08:00", at(20, 8, 0), false},
+		{"2017-03-14T12:00", time.Time{}, true},
+		{"2017-03-01", time.Time{}, true},
+		{"25:00", time.Time{}, true},
+		{"tomorrow", time.Time{}, true},
+	}
+
+	for _, test := range tests {
+		got, err := parseWhen(test.when, now)
+		if (err != nil) != test.err {
+			t.Errorf("%s: unexpected error %v", test.when, err)
+			continue
+		}
+
+		if !got.Equal(test.exp) {
+			t.Errorf("%s: expected %s got %s", test.when, test.exp, got)
+		}
+	}
+}
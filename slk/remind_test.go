@@ -0,0 +1,38 @@
+package slk_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/frizinak/slek/slk"
+)
+
+func TestReminderNotifies(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	srv.AddUser("USLACKBOT", "slackbot")
+	srv.AddIM("D2", "USLACKBOT")
+	srv.Send(
+		map[string]interface{}{
+			"type": "team_join",
+			"user": map[string]string{"id": "USLACKBOT", "name": "slackbot"},
+		},
+	)
+	s.SetNotifyPolicy(slk.NotifyPolicy{Default: slk.NotifyNone})
+	for i := 0; i < 100 && len(s.Fuzzy(slk.TypeUser, "slackbot")) == 0; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	srv.SendMessage("D1", "U1", "hi")
+	srv.SendMessage("D2", "USLACKBOT", "Reminder: stand up.")
+	n := out.Wait("Notify", 1, wait)
+	if len(n) != 1 {
+		t.Fatalf("notifications: %+v", n)
+	}
+
+	if !n[0].Force || n[0].Text != "Reminder: stand up." {
+		t.Errorf("reminder: %+v", n[0])
+	}
+}
@@ -0,0 +1,186 @@
+package slk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	runewidth "github.com/mattn/go-runewidth"
+	"github.com/nlopes/slack"
+)
+
+const (
+	searchPageSize = 20
+	excerptLength  = 120
+	// aroundMaxPages is the maximum amount of history pages fetched to find
+	// the messages following a hit.
+	aroundMaxPages = 5
+)
+
+// search is the last search and the hits of its current page.
+type search struct {
+	query string
+	page  int
+	pages int
+	hits  []slack.SearchMessage
+}
+
+// search fetches and writes the given page of query to the Output.
+func (s *Slk) search(query string, page int) error {
+	p := slack.NewSearchParameters()
+	p.Count = searchPageSize
+	p.Page = page
+
+	res, err := s.c.SearchMessages(query, p)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	s.lastSearch = &search{query, page, res.Paging.Pages, res.Matches}
+	s.mutex.Unlock()
+
+	items := make(ListItems, 1, len(res.Matches)*2+1)
+	items[0] = &ListItem{
+		ListItemStatusTitle,
+		fmt.Sprintf(
+			"Search '%s': page %d/%d (%d hits)",
+			query,
+			res.Paging.Page,
+			res.Paging.Pages,
+			res.Total,
+		),
+	}
+
+	for i := range res.Matches {
+		hit := &res.Matches[i]
+		from := hit.Username
+		if u := s.user(hit.User); !u.IsNil() {
+			from = u.Name()
+		}
+
+		text, _ := s.parseTextIncoming(hit.Text)
+		items = append(
+			items,
+			&ListItem{
+				ListItemStatusNormal,
+				fmt.Sprintf(
+					"[%d] %s %s: %s",
+					i+1,
+					s.searchChannelName(hit),
+					from,
+					ts(hit.Timestamp).Format(s.timeFormat),
+				),
+			},
+			&ListItem{ListItemStatusNone, excerpt(text)},
+		)
+	}
+
+	s.list(items, false)
+	return nil
+}
+
+func (s *Slk) searchChannelName(hit *slack.SearchMessage) string {
+	e := s.entityByChannel(hit.Channel.ID)
+	if e.IsNil() {
+		return "#" + hit.Channel.Name
+	}
+
+	return e.QualifiedName()
+}
+
+// searchIn prefixes query with the search modifier for the given entity.
+func (s *Slk) searchIn(e Entity, query string) (string, error) {
+	switch e.Type() {
+	case TypeUser:
+		return fmt.Sprintf("in:@%s %s", e.Name(), query), nil
+	case TypeChannel:
+		return fmt.Sprintf("in:#%s %s", e.Name(), query), nil
+	}
+
+	return "", fmt.Errorf("Can not search in a %s", e.Type())
+}
+
+func (s *Slk) searchMore() error {
+	s.mutex.RLock()
+	last := s.lastSearch
+	s.mutex.RUnlock()
+
+	if last == nil {
+		return errors.New("Nothing searched yet")
+	}
+
+	if last.page >= last.pages {
+		return errors.New("No more search results")
+	}
+
+	return s.search(last.query, last.page+1)
+}
+
+// searchHit returns the n-th hit (1 based) of the current page.
+func (s *Slk) searchHit(n int) (*slack.SearchMessage, error) {
+	s.mutex.RLock()
+	last := s.lastSearch
+	s.mutex.RUnlock()
+
+	if last == nil {
+		return nil, errors.New("Nothing searched yet")
+	}
+
+	if n < 1 || n > len(last.hits) {
+		return nil, fmt.Errorf("No search result #%d", n)
+	}
+
+	return &last.hits[n-1], nil
+}
+
+// around writes the messages surrounding the one with the given timestamp
+// to the Output.
+func (s *Slk) around(e Entity, timestamp string, amount int) error {
+	p := slack.NewHistoryParameters()
+	p.Latest = timestamp
+	p.Inclusive = true
+	p.Count = amount
+	if _, _, err := s.history(e, p, true); err != nil {
+		return err
+	}
+
+	p = slack.NewHistoryParameters()
+	p.Oldest = timestamp
+	p.Count = historyPageSize
+	msgs, complete, err := s.historyPages(e, p, amount, aroundMaxPages)
+	if err != nil {
+		return err
+	}
+
+	if !complete {
+		s.out.Notice(
+			fmt.Sprintf(
+				"Over %d newer messages, not showing those following the hit",
+				aroundMaxPages*historyPageSize,
+			),
+		)
+		return nil
+	}
+
+	s.renderHistory(e, msgs, false)
+	return nil
+}
+
+// excerpt returns the start of the first non empty line of text.
+func excerpt(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if runewidth.StringWidth(line) > excerptLength {
+			return runewidth.Truncate(line, excerptLength, "...")
+		}
+
+		return line
+	}
+
+	return ""
+}
@@ -0,0 +1,86 @@
+package slk_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/frizinak/slek/slk"
+)
+
+func TestGotoHitContext(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	// More messages after the hit than fit in a single history page.
+	for i := 0; i < 500; i++ {
+		text := fmt.Sprintf("m%d", i)
+		if i == 100 {
+			text = "needle"
+		}
+
+		srv.AddMessage("C1", "U1", text)
+	}
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	s.Switch(c)
+	if err := s.SearchIn(c, "needle"); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := s.GotoHit(1); err != nil {
+		t.Fatal(err)
+	}
+
+	m := out.Wait("Msg", 10, wait)
+	if len(m) != 10 {
+		t.Fatalf("expected 10 messages, got %+v", m)
+	}
+
+	expect := []string{
+		"m96", "m97", "m98", "m99", "needle",
+		"m101", "m102", "m103", "m104", "m105",
+	}
+	for i := range expect {
+		if m[i].Text != expect[i] {
+			t.Errorf("message %d: expected %s, got %s", i, expect[i], m[i].Text)
+		}
+	}
+}
+
+func TestGotoOldHit(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	srv.AddMessage("C1", "U1", "needle")
+	for i := 0; i < 1200; i++ {
+		srv.AddMessage("C1", "U1", fmt.Sprintf("m%d", i))
+	}
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	s.Switch(c)
+	if err := s.SearchIn(c, "needle"); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	calls := len(srv.Calls("channels.history"))
+	if err := s.GotoHit(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// The hit and a page for every aroundMaxPages.
+	if n := len(srv.Calls("channels.history")) - calls; n != 6 {
+		t.Errorf("expected 6 history calls, got %d", n)
+	}
+
+	if m := out.Records("Msg"); len(m) != 1 || m[0].Text != "needle" {
+		t.Errorf("messages: %+v", m)
+	}
+
+	if n := out.Records("Notice"); len(n) != 1 {
+		t.Errorf("notices: %+v", n)
+	}
+}
@@ -3,8 +3,11 @@ package slk
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/nlopes/slack"
@@ -15,28 +18,45 @@ import (
 //
 // Handling of errors returned by Slk exposed methods is optional.
 // Except for Init and Run.
+//
+// Slk is safe for concurrent use, Run handles incoming events
+// while the exported methods can be called from other goroutines.
 type Slk struct {
 	out        Output
-	username   string
 	timeFormat string
 
+	// mutex guards the fields below it.
+	mutex        sync.RWMutex
+	username     string
+	userID       string
 	active       Entity
-	markRead     chan Entity
-	quit         chan error
 	presence     UserPresence
 	lastActivity time.Time
-
-	token string
-
-	c *slack.Client
-	r *slack.RTM
-
-	users          map[string]*user
-	usersByName    map[string]*user
-	channels       map[string]*channel
-	channelsByName map[string]*channel
-	ims            map[string]*slack.IM
-	imsByUser      map[string]*slack.IM
+	reg          *registry
+	following    *followed
+	lastSearch   *search
+	// lastReminders is the last listing of reminders, used to refer to
+	// them by index.
+	lastReminders []Reminder
+	policy        NotifyPolicy
+	prefs         *slackPrefs
+	// highlightWords are our own highlight words, highlight matches
+	// these and slack's.
+	highlightWords []string
+	highlight      *regexp.Regexp
+
+	markMutex sync.Mutex
+	markQueue map[EntityType]map[string]Entity
+
+	recent *recent
+
+	typingMutex sync.Mutex
+	typingSent  map[string]time.Time
+
+	quit chan error
+
+	c API
+	r RTM
 }
 
 // NewSlk returns a new Slk 'engine'.
@@ -45,24 +65,24 @@ func NewSlk(token, timeFormat string, output Output) *Slk {
 	// @see https://github.com/nlopes/slack/issues/27
 	slack.HTTPClient.Timeout = time.Second * 5
 
+	return NewSlkWithAPI(NewAPI(token), timeFormat, output)
+}
+
+// NewSlkWithAPI returns a new Slk 'engine' that uses the given API
+// implementation instead of connecting to slack.
+func NewSlkWithAPI(api API, timeFormat string, output Output) *Slk {
 	return &Slk{
-		output,
-		"",
-		timeFormat,
-		nil,
-		make(chan Entity, 1),
-		make(chan error, 0),
-		UserPresenceActive,
-		time.Now(),
-		token,
-		slack.New(token),
-		nil,
-		map[string]*user{},
-		map[string]*user{},
-		map[string]*channel{},
-		map[string]*channel{},
-		map[string]*slack.IM{},
-		map[string]*slack.IM{},
+		out:          output,
+		timeFormat:   timeFormat,
+		presence:     UserPresenceActive,
+		lastActivity: time.Now(),
+		reg:          newRegistry(),
+		prefs:        newSlackPrefs(),
+		markQueue:    make(map[EntityType]map[string]Entity, 2),
+		recent:       newRecent(),
+		typingSent:   make(map[string]time.Time),
+		quit:         make(chan error, 0),
+		c:            api,
 	}
 }
 
@@ -76,20 +96,43 @@ func (s *Slk) Init() error {
 	s.r = s.c.NewRTM()
 	go s.r.ManageConnection()
 
+	timeout := time.After(time.Second * 5)
 	for {
 		select {
-		case <-time.After(time.Millisecond * 50):
-			d := s.r.GetInfo()
-			if d == nil {
-				continue
+		case e := <-s.r.Events():
+			// Wait for the connected event instead of polling GetInfo
+			// as the latter is written to by the rtm goroutine.
+			d, ok := e.Data.(*slack.ConnectedEvent)
+			if ok && d.Info != nil {
+				s.mutex.Lock()
+				s.username = d.Info.User.Name
+				s.userID = d.Info.User.ID
+				s.mutex.Unlock()
+				// Info.Users lacks the status expirations.
+				if err := s.updateUsers(nil); err != nil {
+					return err
+				}
+				s.updateIMs(d.Info.IMs)
+				s.updateChannels(d.Info.Channels, d.Info.Groups)
+				if err := s.updateUserGroups(nil); err != nil {
+					s.out.Debug("usergroups", err.Error())
+				}
+				if err := s.updateDND(); err != nil {
+					s.out.Debug("dnd", err.Error())
+				}
+				if err := s.updatePrefs(); err != nil {
+					s.out.Debug("prefs", err.Error())
+				}
+			}
+
+			if err := s.handleEvent(e); err != nil {
+				return err
 			}
 
-			s.username = d.User.Name
-			s.updateUsers(d.Users)
-			s.updateIMs(d.IMs)
-			s.updateChannels(d.Channels, d.Groups)
-			return nil
-		case <-time.After(time.Second * 5):
+			if ok {
+				return nil
+			}
+		case <-timeout:
 			return errors.New("Could not establish rtm connection")
 		}
 	}
@@ -98,20 +141,43 @@ func (s *Slk) Init() error {
 // Quit closes the slack RTM connection.
 func (s *Slk) Quit() {
 	s.quit <- nil
-	close(s.markRead)
 	s.r.Disconnect()
-	close(s.r.IncomingEvents)
+}
+
+// SetNotifyPolicy changes when Output.Notify is called.
+func (s *Slk) SetNotifyPolicy(policy NotifyPolicy) {
+	s.mutex.Lock()
+	s.policy = policy
+	s.mutex.Unlock()
+}
+
+// SetHighlightWords sets words that count as a mention of ourselves,
+// in addition to the highlight words in our slack preferences.
+func (s *Slk) SetHighlightWords(words []string) {
+	s.mutex.Lock()
+	s.highlightWords = words
+	s.mutex.Unlock()
+
+	s.updateHighlight()
 }
 
 // Username returns the name of the user whose api key we are using.
 // Will be populated after Init.
 func (s *Slk) Username() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
 	return s.username
 }
 
+func (s *Slk) self() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.userID
+}
+
 // SetPresence updates your presence.
 func (s *Slk) SetPresence(presence UserPresence) error {
-	s.lastActivity = time.Now()
+	s.touch()
 
 	real := string(presence)
 	if presence != UserPresenceAway {
@@ -130,23 +196,149 @@ func (s *Slk) SetPresence(presence UserPresence) error {
 		}
 	}
 
+	s.mutex.Lock()
 	s.presence = presence
+	s.mutex.Unlock()
 	s.out.Notice(fmt.Sprintf("Updated presence to %s", presence))
 	return nil
 }
 
+// SetStatus sets your custom status emoji and text, it is cleared after d
+// unless d is 0. Empty emoji and text clear the status.
+func (s *Slk) SetStatus(emoji, text string, d time.Duration) error {
+	s.touch()
+
+	if err := s.setStatus(emoji, text, d); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	status := strings.TrimSpace(emoji + " " + text)
+	if status == "" {
+		s.out.Notice("Cleared status")
+		return nil
+	}
+
+	if d != 0 {
+		status = fmt.Sprintf("%s (for %s)", status, d)
+	}
+
+	s.out.Notice(fmt.Sprintf("Updated status to %s", status))
+	return nil
+}
+
+// Remind creates a reminder for the given user (nil for yourself).
+// when is either relative (10m, 2h) or absolute (15:04, 2006-01-02 or
+// 2006-01-02T15:04).
+func (s *Slk) Remind(u Entity, when, text string) error {
+	s.touch()
+
+	r, err := s.remind(u, when, text)
+	if err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	t := time.Unix(r.Time, 0)
+	msg := fmt.Sprintf(
+		"Will remind you on %s %s",
+		t.Format(reminderFormat),
+		t.Format(s.timeFormat),
+	)
+	if u != nil && u.ID() != s.self() {
+		msg = fmt.Sprintf(
+			"Will remind %s on %s %s",
+			u.QualifiedName(),
+			t.Format(reminderFormat),
+			t.Format(s.timeFormat),
+		)
+	}
+
+	s.out.Info(msg)
+	return nil
+}
+
+// Reminders writes your reminders to the Output interface.
+func (s *Slk) Reminders() error {
+	s.touch()
+
+	reminders, err := s.reminders()
+	if err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	s.list(s.remindersToList(reminders), false)
+	return nil
+}
+
+// CompleteReminder marks the n-th reminder of the last Reminders listing
+// as done.
+func (s *Slk) CompleteReminder(n int) error {
+	s.touch()
+
+	r, err := s.reminder(n)
+	if err == nil {
+		err = s.c.CompleteReminder(r.ID)
+	}
+
+	if err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	s.out.Info(fmt.Sprintf("Completed reminder: %s", r.Text))
+	return nil
+}
+
+// DeleteReminder deletes the n-th reminder of the last Reminders listing.
+func (s *Slk) DeleteReminder(n int) error {
+	s.touch()
+
+	r, err := s.reminder(n)
+	if err == nil {
+		err = s.c.DeleteReminder(r.ID)
+	}
+
+	if err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	s.out.Info(fmt.Sprintf("Deleted reminder: %s", r.Text))
+	return nil
+}
+
+// Snooze enables do not disturb for the given amount of minutes.
+func (s *Slk) Snooze(minutes int) error {
+	s.touch()
+
+	if err := s.snooze(minutes); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// EndDND ends your current do not disturb period.
+func (s *Slk) EndDND() error {
+	s.touch()
+
+	if err := s.endDND(); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	return nil
+}
+
 // Uploads lists the first api page of uploads of the given entity.
 func (s *Slk) Uploads(e Entity) error {
-	s.lastActivity = time.Now()
+	s.touch()
 
-	var id string
-	switch e.Type() {
-	case TypeUser:
-		id = s.imByUser(e.ID()).ID
-	case TypeChannel:
-		id = e.ID()
-	default:
-		err := fmt.Errorf("Can list uploads of a '%s'", e.Type())
+	id, err := s.channelID(e)
+	if err != nil {
 		s.out.Warn(err.Error())
 		return err
 	}
@@ -178,24 +370,18 @@ func (s *Slk) Uploads(e Entity) error {
 		fmt.Sprintf("files of %s", e.QualifiedName()),
 	}
 
-	s.out.List(items, false)
+	s.list(items, false)
 	return nil
 }
 
 // Upload a file to the given entity.
 func (s *Slk) Upload(e Entity, filepath, title, comment string) chan error {
-	s.lastActivity = time.Now()
+	s.touch()
 
 	ch := make(chan error, 1)
 
-	var id string
-	switch e.Type() {
-	case TypeUser:
-		id = s.imByUser(e.ID()).ID
-	case TypeChannel:
-		id = e.ID()
-	default:
-		err := fmt.Errorf("Can not upload to a '%s'", e.Type())
+	id, err := s.channelID(e)
+	if err != nil {
 		s.out.Warn(err.Error())
 		ch <- err
 		close(ch)
@@ -245,7 +431,7 @@ func (s *Slk) Upload(e Entity, filepath, title, comment string) chan error {
 
 // Invite a user to a channel or group.
 func (s *Slk) Invite(channel, user Entity) error {
-	s.lastActivity = time.Now()
+	s.touch()
 
 	if err := s.invite(channel, user); err != nil {
 		s.out.Warn(err.Error())
@@ -258,7 +444,7 @@ func (s *Slk) Invite(channel, user Entity) error {
 
 // Join makes your user join the given channel or group.
 func (s *Slk) Join(e Entity) error {
-	s.lastActivity = time.Now()
+	s.touch()
 
 	if err := s.join(e); err != nil {
 		s.out.Warn(err.Error())
@@ -269,9 +455,10 @@ func (s *Slk) Join(e Entity) error {
 	return nil
 }
 
-// Leave makes your user leave the given channel or group.
+// Leave makes your user leave the given channel or group or closes the
+// given mpim.
 func (s *Slk) Leave(e Entity) error {
-	s.lastActivity = time.Now()
+	s.touch()
 
 	if err := s.leave(e); err != nil {
 		s.out.Warn(err.Error())
@@ -282,12 +469,118 @@ func (s *Slk) Leave(e Entity) error {
 	return nil
 }
 
+// OpenMPIM opens a multi-party im with the given users.
+func (s *Slk) OpenMPIM(users ...Entity) (Entity, error) {
+	s.touch()
+
+	m, err := s.openMPIM(users)
+	if err != nil {
+		s.out.Warn(err.Error())
+		return nil, err
+	}
+
+	s.out.Info(fmt.Sprintf("Opened %s", m.QualifiedName()))
+	return m, nil
+}
+
+// Create creates a public channel or, if private, a private group.
+func (s *Slk) Create(name string, private bool) (Entity, error) {
+	s.touch()
+
+	e, err := s.create(name, private)
+	if err != nil {
+		s.out.Warn(err.Error())
+		return nil, err
+	}
+
+	s.out.Info(fmt.Sprintf("Created %s", e.QualifiedName()))
+	return e, nil
+}
+
+// Rename renames the given channel or group.
+func (s *Slk) Rename(e Entity, name string) error {
+	s.touch()
+
+	if err := s.rename(e, name); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	s.out.Info(fmt.Sprintf("Renamed %s to #%s", e.QualifiedName(), name))
+	return nil
+}
+
+// Archive archives the given channel or group.
+func (s *Slk) Archive(e Entity) error {
+	s.touch()
+
+	if err := s.archive(e, true); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	s.out.Info(fmt.Sprintf("Archived %s", e.QualifiedName()))
+	return nil
+}
+
+// Unarchive unarchives the given channel or group.
+func (s *Slk) Unarchive(e Entity) error {
+	s.touch()
+
+	if err := s.archive(e, false); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	s.out.Info(fmt.Sprintf("Unarchived %s", e.QualifiedName()))
+	return nil
+}
+
+// Kick removes a user from a channel or group.
+func (s *Slk) Kick(channel, user Entity) error {
+	s.touch()
+
+	if err := s.kick(channel, user); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	s.out.Info(fmt.Sprintf("Kicked %s from %s", user.Name(), channel.Name()))
+	return nil
+}
+
+// SetTopic sets the topic of the given channel or group.
+func (s *Slk) SetTopic(e Entity, topic string) error {
+	s.touch()
+
+	if err := s.setTopic(e, topic); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	s.out.Info(fmt.Sprintf("Updated topic of %s", e.QualifiedName()))
+	return nil
+}
+
+// SetPurpose sets the purpose of the given channel or group.
+func (s *Slk) SetPurpose(e Entity, purpose string) error {
+	s.touch()
+
+	if err := s.setPurpose(e, purpose); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	s.out.Info(fmt.Sprintf("Updated purpose of %s", e.QualifiedName()))
+	return nil
+}
+
 // Joined returns a list of channels and groups you are a member of.
 func (s *Slk) Joined() []Entity {
 	joined := make([]Entity, 0)
-	for i := range s.channels {
-		if s.channels[i].IsActive() {
-			joined = append(joined, s.channels[i])
+	for _, c := range s.entities().channels {
+		if c.IsActive() {
+			joined = append(joined, c)
 		}
 	}
 
@@ -296,36 +589,54 @@ func (s *Slk) Joined() []Entity {
 
 // Switch to the given entity and fetch unread history.
 func (s *Slk) Switch(e Entity) error {
-	s.lastActivity = time.Now()
+	s.touch()
 
+	s.mutex.Lock()
 	if e.Is(s.active) {
+		s.mutex.Unlock()
 		return nil
 	}
 
 	s.active = e
+	s.following = nil
+	s.mutex.Unlock()
+
 	if err := s.Unread(e); err != nil {
 		return err
 	}
 
+	name := nameWithStatus(e, e.QualifiedName())
+	if ch, ok := e.(*channel); ok {
+		if topic, _ := ch.info(); topic != "" {
+			topic, _ = s.parseTextIncoming(topic)
+			name = fmt.Sprintf("%s: %s", name, topic)
+		}
+	}
+
+	if ch, ok := e.(*channel); ok && ch.isArchived() {
+		s.out.Info(fmt.Sprintf("%s [archived]", name))
+		return nil
+	}
+
 	if e.Type() == TypeChannel && !e.IsActive() {
 		s.out.Info(
 			fmt.Sprintf(
 				"%s [not a member, join to receive live messages]",
-				e.QualifiedName(),
+				name,
 			),
 		)
 
 		return nil
 	}
 
-	s.out.Info(e.QualifiedName())
+	s.out.Info(name)
 
 	return nil
 }
 
 // Active returns the active entity.
 func (s *Slk) Active() (Entity, error) {
-	active := s.active
+	active := s.activeEntity()
 	if active == nil {
 		return nil, errors.New("No active channel")
 	}
@@ -333,24 +644,171 @@ func (s *Slk) Active() (Entity, error) {
 	return active, nil
 }
 
-// IMs returns a list of users you have intiated an IM channel with.
+// IMs returns a list of users you have an open IM channel with.
 func (s *Slk) IMs() []Entity {
+	reg := s.entities()
 	users := make([]Entity, 0)
-	for i := range s.users {
-		im := s.imByUser(s.users[i].ID())
-		if im != nilIM {
-			users = append(users, s.users[i])
+	for _, u := range reg.users {
+		im := reg.imByUser(u.ID())
+		if im != nilIM && im.IsOpen {
+			users = append(users, u)
 		}
 	}
 
 	return users
 }
 
-// Post a message to the given user, channel or group.
+// Post a message to the given user, channel, group or mpim.
 func (s *Slk) Post(e Entity, msg string) error {
-	s.lastActivity = time.Now()
+	s.touch()
+
+	if err := s.post(e, msg, ""); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Typing lets others in the given user, channel, group or mpim know we
+// are typing, at most once every few seconds.
+// Errors are not written to the Output as this is called on keypresses.
+func (s *Slk) Typing(e Entity) error {
+	return s.typing(e)
+}
+
+// Thread writes the parent and all replies of the thread of the message
+// with the given timestamp to the Output interface.
+// New replies will be appended to the thread until another list is written,
+// CloseThread is called or a different entity becomes active.
+func (s *Slk) Thread(e Entity, ts string) error {
+	s.touch()
+
+	var items ListItems
+	id, err := s.channelID(e)
+	if err == nil {
+		if m := s.recent.get(id, ts); m != nil && m.thread != "" {
+			ts = m.thread
+		}
+
+		items, err = s.threadItems(e, id, ts)
+	}
+
+	if err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	s.out.List(items, false)
+	s.follow(&followed{e, id, ts, items})
+	return nil
+}
+
+// CloseThread stops following the thread opened with Thread.
+func (s *Slk) CloseThread() {
+	s.follow(nil)
+}
+
+// AddReaction adds the given emoji as a reaction to the message with the
+// given timestamp.
+func (s *Slk) AddReaction(e Entity, ts, emoji string) error {
+	s.touch()
+
+	if err := s.react(e, ts, emoji, true); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// RemoveReaction removes your emoji reaction from the message with the
+// given timestamp.
+func (s *Slk) RemoveReaction(e Entity, ts, emoji string) error {
+	s.touch()
+
+	if err := s.react(e, ts, emoji, false); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Recent returns the timestamp of the n-th most recent message
+// (1 = latest) in the given user, channel or group.
+func (s *Slk) Recent(e Entity, n int) (string, error) {
+	_, m, err := s.nth(e, n)
+	if err != nil {
+		s.out.Warn(err.Error())
+		return "", err
+	}
+
+	return m.ts, nil
+}
+
+// Reply posts a message in the thread of the message with the given
+// timestamp. If that message is a reply itself, its thread is used.
+func (s *Slk) Reply(e Entity, ts, msg string) error {
+	s.touch()
+
+	id, err := s.channelID(e)
+	if err == nil {
+		if m := s.recent.get(id, ts); m != nil && m.thread != "" {
+			ts = m.thread
+		}
+
+		err = s.post(e, msg, ts)
+	}
+
+	if err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// EditLast replaces the text of your last message in the given user,
+// channel or group.
+func (s *Slk) EditLast(e Entity, msg string) error {
+	s.touch()
+
+	replace := func(string) (string, error) { return msg, nil }
+	if err := s.editLast(e, replace); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// ReplaceLast replaces the first occurrence of old with new in your
+// last message in the given user, channel or group.
+func (s *Slk) ReplaceLast(e Entity, old, new string) error {
+	s.touch()
+
+	replace := func(msg string) (string, error) {
+		if !strings.Contains(msg, old) {
+			return "", fmt.Errorf("'%s' not found in your last message", old)
+		}
+
+		return strings.Replace(msg, old, new, 1), nil
+	}
 
-	if err := s.post(e, msg); err != nil {
+	if err := s.editLast(e, replace); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// DeleteLast deletes your last message in the given user, channel or group.
+func (s *Slk) DeleteLast(e Entity) error {
+	s.touch()
+
+	if err := s.deleteLast(e); err != nil {
 		s.out.Warn(err.Error())
 		return err
 	}
@@ -361,7 +819,7 @@ func (s *Slk) Post(e Entity, msg string) error {
 // Unread writes all unread mesages of the given user, channel or group
 // to the Output interface and marks the last message as read.
 func (s *Slk) Unread(e Entity) error {
-	s.lastActivity = time.Now()
+	s.touch()
 
 	last := e.lastRead()
 	latest := e.latest()
@@ -377,27 +835,16 @@ func (s *Slk) Unread(e Entity) error {
 
 	p := slack.NewHistoryParameters()
 	p.Oldest = last
+	p.Count = historyPageSize
 
-	var latestFetched string
-	var done bool
-	var err error
-	first := true
-	for {
-		latestFetched, done, err = s.history(e, p, first)
-		first = false
-		if err != nil {
-			s.out.Warn(err.Error())
-			return err
-		}
-
-		if done {
-			break
-		}
-
-		p.Oldest = latestFetched
+	msgs, _, err := s.historyPages(e, p, 0, 0)
+	if err != nil {
+		s.out.Warn(err.Error())
+		return err
 	}
 
-	s.markRead <- e
+	s.renderHistory(e, msgs, true)
+	s.queueMark(e)
 	return nil
 }
 
@@ -405,7 +852,7 @@ func (s *Slk) Unread(e Entity) error {
 // but takes an amount of messages argument instead of looking up unread
 // messages.
 func (s *Slk) History(e Entity, amount int) error {
-	s.lastActivity = time.Now()
+	s.touch()
 
 	p := slack.NewHistoryParameters()
 	p.Count = amount
@@ -419,76 +866,132 @@ func (s *Slk) History(e Entity, amount int) error {
 
 	if done {
 		e.setLatest(latest)
-		s.markRead <- e
+		s.queueMark(e)
 	}
 
 	return nil
 }
 
-// Pins writes the last 100 (?) pins of a channel or group to the
+// Search writes the first page of messages matching query to the
 // Output interface.
-func (s *Slk) Pins(e Entity) error {
-	s.lastActivity = time.Now()
+func (s *Slk) Search(query string) error {
+	s.touch()
 
-	var err error
-	var items []slack.Item
+	if err := s.search(query, 1); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
 
-	if e.Type() != TypeChannel {
-		err = fmt.Errorf("Can't list pins of a %s", e.Type())
+	return nil
+}
+
+// SearchIn is like Search but only matches messages in the given user,
+// channel or group.
+func (s *Slk) SearchIn(e Entity, query string) error {
+	s.touch()
+
+	query, err := s.searchIn(e, query)
+	if err == nil {
+		err = s.search(query, 1)
+	}
+
+	if err != nil {
 		s.out.Warn(err.Error())
 		return err
 	}
 
-	items, _, err = s.c.ListPins(e.ID())
+	return nil
+}
+
+// SearchMore writes the next page of the last search to the Output
+// interface.
+func (s *Slk) SearchMore() error {
+	s.touch()
+
+	if err := s.searchMore(); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// GotoHit switches to the channel of the n-th result on the current
+// search page and writes the messages surrounding it to the Output interface.
+func (s *Slk) GotoHit(n int) error {
+	s.touch()
+
+	hit, err := s.searchHit(n)
 	if err != nil {
 		s.out.Warn(err.Error())
 		return err
 	}
 
-	listItems := make(ListItems, 0, len(items)+1)
-	for i := range items {
-		var url string
-		var msg string
-		var from = nilUser
-		var timestamp time.Time
+	e := s.entityByChannel(hit.Channel.ID)
+	if e.IsNil() {
+		err = fmt.Errorf("Unknown channel #%s", hit.Channel.Name)
+		s.out.Warn(err.Error())
+		return err
+	}
 
-		if items[i].File != nil {
-			from = s.user(items[i].File.User)
-			url = items[i].File.URLPrivate
-			timestamp = items[i].File.Timestamp.Time()
-		}
+	if err = s.Switch(e); err != nil {
+		return err
+	}
 
-		if items[i].Message != nil {
-			if from.IsNil() {
-				from = s.user(items[i].Message.User)
-			}
-			msg = items[i].Message.Text
-			timestamp = ts(items[i].Message.Timestamp)
-		}
+	if err = s.around(e, hit.Timestamp, 5); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
 
-		// Don't return early so we know parsing is flawed
-		// if only the username and stamp are shown without msg or url.
+	return nil
+}
 
-		if msg == "" {
-			listItems = append(listItems, &ListItem{ListItemStatusNone, url})
-		} else {
-			listItems = append(listItems, &ListItem{ListItemStatusNone, msg})
-		}
+// Pin pins the message with the given timestamp to the given channel
+// or group.
+func (s *Slk) Pin(e Entity, ts string) error {
+	s.touch()
 
-		listItems = append(
-			listItems,
-			&ListItem{
-				ListItemStatusNormal,
-				fmt.Sprintf(
-					"%s: %s",
-					from.QualifiedName(),
-					timestamp.Format(s.timeFormat),
-				),
-			},
-		)
+	if err := s.pin(e, ts, true); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Unpin removes the pin of the message with the given timestamp.
+func (s *Slk) Unpin(e Entity, ts string) error {
+	s.touch()
+
+	if err := s.pin(e, ts, false); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	return nil
+}
 
+// Pins writes the last 100 (?) pins of a channel or group to the
+// Output interface.
+func (s *Slk) Pins(e Entity) error {
+	s.touch()
+
+	var err error
+	var items []slack.Item
+
+	if e.Type() != TypeChannel {
+		err = fmt.Errorf("Can't list pins of a %s", e.Type())
+		s.out.Warn(err.Error())
+		return err
 	}
 
+	items, _, err = s.c.ListPins(e.ID())
+	if err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	listItems := s.itemsToList(items, false)
 	listItems = append(
 		listItems,
 		&ListItem{
@@ -497,7 +1000,7 @@ func (s *Slk) Pins(e Entity) error {
 		},
 	)
 
-	s.out.List(
+	s.list(
 		listItems,
 		true,
 	)
@@ -505,40 +1008,143 @@ func (s *Slk) Pins(e Entity) error {
 	return nil
 }
 
+// Star stars the message with the given timestamp.
+func (s *Slk) Star(e Entity, ts string) error {
+	s.touch()
+
+	if err := s.star(e, ts, true); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Unstar removes the star of the message with the given timestamp.
+func (s *Slk) Unstar(e Entity, ts string) error {
+	s.touch()
+
+	if err := s.star(e, ts, false); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Stars writes all your starred items to the Output interface.
+func (s *Slk) Stars() error {
+	s.touch()
+
+	items, err := s.starred()
+	if err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	listItems := s.itemsToList(items, true)
+	listItems = append(
+		listItems,
+		&ListItem{ListItemStatusTitle, "Starred items"},
+	)
+
+	s.list(listItems, true)
+
+	return nil
+}
+
 // Fuzzy returns a list of entities of type entityType whose names fuzzy match
 // the given query.
 func (s *Slk) Fuzzy(entityType EntityType, query string) []Entity {
 	lookup := map[string]Entity{}
+	reg := s.entities()
 
 	switch entityType {
 	case TypeChannel:
-		lookup = make(map[string]Entity, len(s.channelsByName))
-		for i := range s.channelsByName {
-			lookup[i] = s.channelsByName[i]
+		lookup = make(map[string]Entity, len(reg.channelsByName))
+		for i := range reg.channelsByName {
+			lookup[i] = reg.channelsByName[i]
 		}
 	case TypeUser:
-		lookup = make(map[string]Entity, len(s.usersByName))
-		for i := range s.usersByName {
-			lookup[i] = s.usersByName[i]
+		lookup = make(map[string]Entity, len(reg.usersByName))
+		for i := range reg.usersByName {
+			lookup[i] = reg.usersByName[i]
+		}
+	case TypeMPIM:
+		lookup = make(map[string]Entity, len(reg.mpimsByName))
+		for i := range reg.mpimsByName {
+			lookup[i] = reg.mpimsByName[i]
 		}
 	}
 
 	return fuzzySearch(query, lookup)
 }
 
-// NextUnread returns a random entity (ims first) with unread messages.
+// Lookup returns the entity of the given type with exactly the given name.
+func (s *Slk) Lookup(entityType EntityType, name string) (Entity, bool) {
+	reg := s.entities()
+	switch entityType {
+	case TypeChannel:
+		if c, ok := reg.channelsByName[name]; ok {
+			return c, true
+		}
+	case TypeUser:
+		if u, ok := reg.usersByName[name]; ok {
+			return u, true
+		}
+	case TypeMPIM:
+		if m, ok := reg.mpimsByName[name]; ok {
+			return m, true
+		}
+	}
+
+	return nil, false
+}
+
+// FuzzyMention returns the user names and user group handles that fuzzy
+// match the given query.
+func (s *Slk) FuzzyMention(query string) []string {
+	reg := s.entities()
+	targets := make([]string, 0, len(reg.usersByName)+len(reg.groupsByHandle))
+	for name := range reg.usersByName {
+		targets = append(targets, name)
+	}
+
+	for handle := range reg.groupsByHandle {
+		targets = append(targets, handle)
+	}
+
+	return fuzzyStrings(query, targets)
+}
+
+// NextUnread returns a random entity (ims and mpims first) with unread
+// messages.
 func (s *Slk) NextUnread() (Entity, error) {
-	s.lastActivity = time.Now()
+	s.touch()
+
+	reg := s.entities()
+	active := s.activeEntity()
+	unread := func(e Entity) bool {
+		return !e.Is(active) &&
+			e.UnreadCount() != 0 &&
+			s.notifyMode(e) != NotifyMute
+	}
 
-	for i := range s.users {
-		if !s.users[i].Is(s.active) && s.users[i].UnreadCount() != 0 {
-			return s.users[i], nil
+	for _, u := range reg.users {
+		if unread(u) {
+			return u, nil
 		}
 	}
 
-	for i := range s.channels {
-		if !s.channels[i].Is(s.active) && s.channels[i].UnreadCount() != 0 {
-			return s.channels[i], nil
+	for _, m := range reg.mpims {
+		if unread(m) {
+			return m, nil
+		}
+	}
+
+	for _, c := range reg.channels {
+		if unread(c) {
+			return c, nil
 		}
 	}
 
@@ -547,51 +1153,64 @@ func (s *Slk) NextUnread() (Entity, error) {
 
 // ListUnread writes a list of entities with unread messages to the Output.
 func (s *Slk) ListUnread() error {
+	reg := s.entities()
 	userList := make(ListItems, 0)
+	mpimList := make(ListItems, 0)
 	channelList := make(ListItems, 0)
 
-	for i := range s.users {
-		if s.users[i].UnreadCount() != 0 {
+	for _, u := range reg.users {
+		if ur := u.UnreadCount(); ur != 0 {
 			userList = append(
 				userList,
 				&ListItem{
 					ListItemStatusNormal,
-					fmt.Sprintf(
-						"%-18s [%d]",
-						s.users[i].QualifiedName(),
-						s.users[i].UnreadCount(),
-					),
+					fmt.Sprintf("%-18s [%d]", u.QualifiedName(), ur),
+				},
+			)
+		}
+	}
+
+	for _, m := range reg.mpims {
+		if ur := m.UnreadCount(); ur != 0 {
+			mpimList = append(
+				mpimList,
+				&ListItem{
+					ListItemStatusNormal,
+					fmt.Sprintf("%-18s [%d]", m.QualifiedName(), ur),
 				},
 			)
 		}
 	}
 
-	for i := range s.channels {
-		if s.channels[i].UnreadCount() != 0 {
+	for _, c := range reg.channels {
+		if ur := c.UnreadCount(); ur != 0 {
 			channelList = append(
 				channelList,
 				&ListItem{
 					ListItemStatusNormal,
-					fmt.Sprintf(
-						"%-18s [%d]",
-						s.channels[i].QualifiedName(),
-						s.channels[i].UnreadCount(),
-					),
+					fmt.Sprintf("%-18s [%d]", c.QualifiedName(), ur),
 				},
 			)
 		}
 	}
 
 	sort.Sort(userList)
+	sort.Sort(mpimList)
 	sort.Sort(channelList)
 
-	list := make(ListItems, 0, len(userList)+len(channelList)+2)
+	list := make(
+		ListItems,
+		0,
+		len(userList)+len(mpimList)+len(channelList)+3,
+	)
 	list = append(list, &ListItem{ListItemStatusTitle, "Users:"})
 	list = append(list, userList...)
+	list = append(list, &ListItem{ListItemStatusTitle, "Group messages:"})
+	list = append(list, mpimList...)
 	list = append(list, &ListItem{ListItemStatusTitle, "Channels:"})
 	list = append(list, channelList...)
 
-	s.out.List(list, false)
+	s.list(list, false)
 	return nil
 }
 
@@ -610,19 +1229,26 @@ func (s *Slk) List(entityType EntityType, relevantOnly bool) error {
 			status = ListItemStatusNormal
 		}
 
+		if u, ok := e.(*user); ok && u.inDND() {
+			status = ListItemStatusBad
+		}
+
 		txt := e.Name()
 		if ur := e.UnreadCount(); ur != 0 {
 			txt = fmt.Sprintf("%-18s [%d]", txt, ur)
 		}
 
+		txt = nameWithStatus(e, txt)
+
 		return &ListItem{status, txt}
 	}
 
+	reg := s.entities()
 	switch entityType {
 	case TypeChannel:
 		title = "Channels:"
-		items = make(ListItems, 0, len(s.channels))
-		for _, c := range s.channels {
+		items = make(ListItems, 0, len(reg.channels))
+		for _, c := range reg.channels {
 			if item := create(c); item != nil {
 				items = append(items, item)
 			}
@@ -630,12 +1256,21 @@ func (s *Slk) List(entityType EntityType, relevantOnly bool) error {
 
 	case TypeUser:
 		title = "Users:"
-		items = make(ListItems, 0, len(s.users))
-		for _, u := range s.users {
+		items = make(ListItems, 0, len(reg.users))
+		for _, u := range reg.users {
 			if item := create(u); item != nil {
 				items = append(items, item)
 			}
 		}
+
+	case TypeMPIM:
+		title = "Group messages:"
+		items = make(ListItems, 0, len(reg.mpims))
+		for _, m := range reg.mpims {
+			if item := create(m); item != nil {
+				items = append(items, item)
+			}
+		}
 	}
 
 	if items != nil {
@@ -643,7 +1278,7 @@ func (s *Slk) List(entityType EntityType, relevantOnly bool) error {
 		_items := make(ListItems, 1, len(items)+1)
 		_items[0] = &ListItem{ListItemStatusTitle, title}
 		_items = append(_items, items...)
-		s.out.List(_items, false)
+		s.list(_items, false)
 		return nil
 	}
 
@@ -654,7 +1289,7 @@ func (s *Slk) List(entityType EntityType, relevantOnly bool) error {
 }
 
 // Members writes a list of members of the given channel or group to the
-// Output interface.
+// Output interface. Members are refetched if they were not synced recently.
 func (s *Slk) Members(e Entity, relevantOnly bool) error {
 	channel, ok := e.(*channel)
 	if !ok {
@@ -663,9 +1298,15 @@ func (s *Slk) Members(e Entity, relevantOnly bool) error {
 		return err
 	}
 
-	items := make(ListItems, 0, len(channel.members))
-	for i := range channel.members {
-		user := s.user(channel.members[i])
+	if err := s.syncMembers(channel); err != nil {
+		s.out.Warn(err.Error())
+		return err
+	}
+
+	members := channel.memberIDs()
+	items := make(ListItems, 0, len(members))
+	for i := range members {
+		user := s.user(members[i])
 		status := ListItemStatusGood
 		if !user.IsActive() {
 			if relevantOnly {
@@ -675,7 +1316,14 @@ func (s *Slk) Members(e Entity, relevantOnly bool) error {
 			status = ListItemStatusNormal
 		}
 
-		items = append(items, &ListItem{status, user.Name()})
+		if user.inDND() {
+			status = ListItemStatusBad
+		}
+
+		items = append(
+			items,
+			&ListItem{status, nameWithStatus(user, user.Name())},
+		)
 	}
 
 	sort.Sort(items)
@@ -684,9 +1332,13 @@ func (s *Slk) Members(e Entity, relevantOnly bool) error {
 		ListItemStatusTitle,
 		fmt.Sprintf("Users in %s", channel.QualifiedName()),
 	}
+	if _, purpose := channel.info(); purpose != "" {
+		purpose, _ = s.parseTextIncoming(purpose)
+		_items = append(_items, &ListItem{ListItemStatusNone, purpose})
+	}
 	_items = append(_items, items...)
 
-	s.out.List(
+	s.list(
 		_items,
 		false,
 	)
@@ -701,8 +1353,6 @@ func (s *Slk) Run() error {
 		return errors.New("Forgot to call Init()?")
 	}
 
-	markReadEntities := make(map[EntityType]map[string]Entity, 2)
-
 	markTimeout := time.After(time.Second * 5)
 	active := time.Minute * 5
 	activeTimeout := time.After(active)
@@ -712,39 +1362,70 @@ func (s *Slk) Run() error {
 		case err := <-s.quit:
 			return err
 
-		case e := <-s.r.IncomingEvents:
+		case e := <-s.r.Events():
 			if err := s.handleEvent(e); err != nil {
 				s.Quit()
 				return err
 			}
 
-		case e := <-s.markRead:
-			typ := e.Type()
-			if _, ok := markReadEntities[typ]; !ok {
-				markReadEntities[typ] = make(map[string]Entity)
-			}
-			markReadEntities[typ][e.ID()] = e
-			e.resetUnread()
 		case <-activeTimeout:
-			if s.presence == UserPresenceActive &&
-				s.lastActivity.Add(active).After(time.Now()) {
+			s.mutex.RLock()
+			update := s.presence == UserPresenceActive &&
+				s.lastActivity.Add(active).After(time.Now())
+			s.mutex.RUnlock()
+			if update {
 				s.out.Debug("update user activity")
 				s.c.SetUserAsActive()
 			}
 			activeTimeout = time.After(active)
 
 		case <-markTimeout:
-		Outer:
-			for typ := range markReadEntities {
-				for name, e := range markReadEntities[typ] {
-					s.mark(e)
-					delete(markReadEntities[typ], name)
-
-					break Outer
-				}
+			if e := s.nextMark(); e != nil {
+				s.mark(e)
 			}
 
 			markTimeout = time.After(time.Second * 5)
 		}
 	}
 }
+
+// touch registers user activity.
+func (s *Slk) touch() {
+	s.mutex.Lock()
+	s.lastActivity = time.Now()
+	s.mutex.Unlock()
+}
+
+func (s *Slk) activeEntity() Entity {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.active
+}
+
+// queueMark resets the unread count of the given entity and queues it
+// to be marked as read by Run.
+func (s *Slk) queueMark(e Entity) {
+	s.markMutex.Lock()
+	defer s.markMutex.Unlock()
+	typ := e.Type()
+	if _, ok := s.markQueue[typ]; !ok {
+		s.markQueue[typ] = make(map[string]Entity)
+	}
+
+	s.markQueue[typ][e.ID()] = e
+	e.resetUnread()
+}
+
+// nextMark pops an entity from the mark queue.
+func (s *Slk) nextMark() Entity {
+	s.markMutex.Lock()
+	defer s.markMutex.Unlock()
+	for typ := range s.markQueue {
+		for id, e := range s.markQueue[typ] {
+			delete(s.markQueue[typ], id)
+			return e
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package slk_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/frizinak/slek/slk"
+	"github.com/frizinak/slek/slk/slktest"
+)
+
+const wait = time.Second
+
+// start returns a Server with ourselves (U0 me), bob (U1), #general (C1)
+// and an im with bob (D1) and an initialized Slk connected to it.
+func start(t *testing.T) (*slktest.Server, *slktest.Output, *slk.Slk) {
+	srv := slktest.NewServer("U0", "me")
+	srv.AddUser("U1", "bob")
+	srv.AddChannel("C1", "general", true, "U0", "U1")
+	srv.AddIM("D1", "U1")
+
+	out := slktest.NewOutput()
+	s := slk.NewSlk(slktest.Token, "15:04", out)
+	if err := s.Init(); err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+
+	go s.Run()
+	for i := 0; i < 100 && srv.Connected() == 0; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	return srv, out, s
+}
+
+// entity returns the only entity of the given type matching name.
+func entity(t *testing.T, s *slk.Slk, typ slk.EntityType, name string) slk.Entity {
+	e := s.Fuzzy(typ, name)
+	if len(e) != 1 {
+		t.Fatalf("expected a single %s %s, got %v", typ, name, e)
+	}
+
+	return e[0]
+}
+
+func TestInit(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	if s.Username() != "me" {
+		t.Errorf("username: %s", s.Username())
+	}
+
+	entity(t, s, slk.TypeUser, "bob")
+	c := entity(t, s, slk.TypeChannel, "general")
+	if c.QualifiedName() != "#general" {
+		t.Errorf("channel: %s", c.QualifiedName())
+	}
+
+	if _, ok := s.Lookup(slk.TypeChannel, "general"); !ok {
+		t.Error("lookup of #general failed")
+	}
+
+	if e, ok := s.Lookup(slk.TypeChannel, "gen"); ok {
+		t.Errorf("partial lookup matched %s", e.QualifiedName())
+	}
+
+	if len(s.IMs()) != 1 {
+		t.Errorf("ims: %v", s.IMs())
+	}
+
+	if n := out.Wait("Notice", 1, wait); len(n) == 0 {
+		t.Errorf("no connected notice: %+v", out.Records(""))
+	}
+
+	if w := out.Records("Warn"); len(w) != 0 {
+		t.Errorf("warnings: %+v", w)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	s.Switch(c)
+
+	srv.SendMessage("C1", "U1", "hello <@U0>")
+	m := out.Wait("Msg", 1, wait)
+	if len(m) != 1 {
+		t.Fatalf("msg: %+v", out.Records(""))
+	}
+
+	if m[0].Channel != "#general" || m[0].From != "bob" {
+		t.Errorf("msg: %+v", m[0])
+	}
+
+	if m[0].Text != "hello @me" {
+		t.Errorf("text: %s", m[0].Text)
+	}
+
+	if n := out.Wait("Notify", 1, wait); len(n) != 1 {
+		t.Errorf("mention did not notify: %+v", out.Records(""))
+	}
+
+	// Messages in other channels only count as unread.
+	out.Reset()
+	srv.SendMessage("D1", "U1", "psst")
+	out.Wait("Notify", 1, wait)
+	if m := out.Records("Msg"); len(m) != 0 {
+		t.Errorf("inactive im rendered: %+v", m)
+	}
+
+	if bob := entity(t, s, slk.TypeUser, "bob"); bob.UnreadCount() != 1 {
+		t.Errorf("unread: %d", bob.UnreadCount())
+	}
+}
+
+func TestPost(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	s.Switch(c)
+	if err := s.Post(c, "a <b> & c"); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := srv.Calls("chat.postMessage")
+	if len(calls) != 1 {
+		t.Fatalf("calls: %+v", calls)
+	}
+
+	v := calls[0].Values
+	if v.Get("channel") != "C1" || v.Get("text") != "a &lt;b&gt; &amp; c" {
+		t.Errorf("post: %v", v)
+	}
+
+	m := out.Wait("Msg", 1, wait)
+	if len(m) != 1 || m[0].From != "me" || m[0].Text != "a <b> & c" {
+		t.Errorf("msg: %+v", out.Records(""))
+	}
+
+	bob := entity(t, s, slk.TypeUser, "bob")
+	if err := s.Post(bob, "hi"); err != nil {
+		t.Fatal(err)
+	}
+
+	calls = srv.Calls("chat.postMessage")
+	if len(calls) != 2 || calls[1].Values.Get("channel") != "D1" {
+		t.Errorf("calls: %+v", calls)
+	}
+}
+
+// TestConcurrent switches, posts and lists while events are handled,
+// run with -race.
+func TestConcurrent(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	srv.AddChannel("C2", "random", true, "U0")
+	c := entity(t, s, slk.TypeChannel, "general")
+	bob := entity(t, s, slk.TypeUser, "bob")
+
+	const n = 20
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < n; i++ {
+			srv.SendMessage("C1", "U1", "hello")
+			srv.SendMessage("D1", "U1", "hi <@U0>")
+			srv.Send(
+				map[string]interface{}{
+					"type":    "member_joined_channel",
+					"channel": "C1",
+					"user":    "U2",
+				},
+			)
+			srv.Send(
+				map[string]interface{}{
+					"type":    "member_left_channel",
+					"channel": "C404",
+					"user":    "U1",
+				},
+			)
+			srv.Send(
+				map[string]interface{}{
+					"type":     "presence_change",
+					"user":     "U1",
+					"presence": "away",
+				},
+			)
+		}
+		close(done)
+	}()
+
+	for i := 0; i < n; i++ {
+		s.Switch(c)
+		if err := s.Post(c, "yo"); err != nil {
+			t.Error(err)
+		}
+
+		s.Switch(bob)
+		if err := s.Post(bob, "yo"); err != nil {
+			t.Error(err)
+		}
+
+		s.Fuzzy(slk.TypeChannel, "ra")
+		s.List(slk.TypeUser, false)
+		s.ListUnread()
+		s.NextUnread()
+	}
+
+	<-done
+	if p := srv.Calls("chat.postMessage"); len(p) != 2*n {
+		t.Errorf("expected %d posts, got %d", 2*n, len(p))
+	}
+
+	// Incoming messages are only rendered in the active entity.
+	if m := out.Wait("Msg", 2*n, wait); len(m) < 2*n {
+		t.Errorf("expected at least %d messages, got %d", 2*n, len(m))
+	}
+
+	if w := out.Records("Warn"); len(w) != 0 {
+		t.Errorf("warnings: %+v", w)
+	}
+}
@@ -0,0 +1,195 @@
+package slktest
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frizinak/slek/slk"
+)
+
+var _ slk.Output = &Output{}
+
+// Record is a single call to one of the slk.Output methods.
+// Only the fields relevant to Method are set.
+type Record struct {
+	Method  string
+	Channel string
+	From    string
+	Text    string
+	Prev    string
+	TS      time.Time
+	Since   time.Time
+	Thread  *slk.Thread
+	Force   bool
+	Section bool
+	Items   slk.ListItems
+}
+
+// Output is a slk.Output implementation that records all calls.
+type Output struct {
+	mutex   sync.Mutex
+	records []Record
+}
+
+// NewOutput returns an empty Output.
+func NewOutput() *Output {
+	return &Output{records: make([]Record, 0)}
+}
+
+func (o *Output) record(r Record) {
+	o.mutex.Lock()
+	o.records = append(o.records, r)
+	o.mutex.Unlock()
+}
+
+// Records returns all recorded calls of the given method (e.g.: "Msg")
+// or all calls if method is empty.
+func (o *Output) Records(method string) []Record {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	records := make([]Record, 0, len(o.records))
+	for i := range o.records {
+		if method == "" || o.records[i].Method == method {
+			records = append(records, o.records[i])
+		}
+	}
+
+	return records
+}
+
+// Wait blocks until at least n calls of the given method were recorded
+// or the timeout expires. The recorded calls are returned in both cases.
+func (o *Output) Wait(method string, n int, timeout time.Duration) []Record {
+	deadline := time.Now().Add(timeout)
+	for {
+		records := o.Records(method)
+		if len(records) >= n || time.Now().After(deadline) {
+			return records
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+// Reset forgets all recorded calls.
+func (o *Output) Reset() {
+	o.mutex.Lock()
+	o.records = o.records[:0]
+	o.mutex.Unlock()
+}
+
+func (o *Output) Notify(channel, from, text string, force bool) {
+	o.record(
+		Record{
+			Method:  "Notify",
+			Channel: channel,
+			From:    from,
+			Text:    text,
+			Force:   force,
+		},
+	)
+}
+
+func (o *Output) Info(msg string) {
+	o.record(Record{Method: "Info", Text: msg})
+}
+
+func (o *Output) Notice(msg string) {
+	o.record(Record{Method: "Notice", Text: msg})
+}
+
+func (o *Output) Warn(msg string) {
+	o.record(Record{Method: "Warn", Text: msg})
+}
+
+func (o *Output) Msg(
+	channel,
+	from,
+	msg string,
+	ts time.Time,
+	thread *slk.Thread,
+	section bool,
+) {
+	o.record(
+		Record{
+			Method:  "Msg",
+			Channel: channel,
+			From:    from,
+			Text:    msg,
+			TS:      ts,
+			Thread:  thread,
+			Section: section,
+		},
+	)
+}
+
+func (o *Output) Edit(channel, from, prev, msg string, ts time.Time) {
+	o.record(
+		Record{
+			Method:  "Edit",
+			Channel: channel,
+			From:    from,
+			Prev:    prev,
+			Text:    msg,
+			TS:      ts,
+		},
+	)
+}
+
+func (o *Output) Delete(channel, from, msg string, ts time.Time) {
+	o.record(
+		Record{
+			Method:  "Delete",
+			Channel: channel,
+			From:    from,
+			Text:    msg,
+			TS:      ts,
+		},
+	)
+}
+
+func (o *Output) Debug(msg ...string) {
+	o.record(Record{Method: "Debug", Text: strings.Join(msg, " ")})
+}
+
+func (o *Output) Typing(channel, user string, timeout time.Duration) {
+	o.record(Record{Method: "Typing", Channel: channel, From: user})
+}
+
+func (o *Output) File(channel, from, title, url string) {
+	o.record(
+		Record{
+			Method:  "File",
+			Channel: channel,
+			From:    from,
+			Text:    title + " " + url,
+		},
+	)
+}
+
+func (o *Output) DND(from, until time.Time) {
+	o.record(Record{Method: "DND", Since: from, TS: until})
+}
+
+func (o *Output) Highlight(re *regexp.Regexp) {
+	text := ""
+	if re != nil {
+		text = re.String()
+	}
+
+	o.record(Record{Method: "Highlight", Text: text})
+}
+
+func (o *Output) List(items slk.ListItems, reverse bool) {
+	list := make(slk.ListItems, len(items))
+	copy(list, items)
+	if reverse {
+		for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+			list[i], list[j] = list[j], list[i]
+		}
+	}
+
+	o.record(Record{Method: "List", Items: list})
+}
@@ -0,0 +1,1553 @@
+// Package slktest provides a local stand-in for the slack web and rtm api
+// and a recording slk.Output so slk (and code built on top of it) can be
+// tested without a slack team, token or network connection.
+//
+// The nlopes/slack client talks to the url in the slack.SLACK_API package
+// global, NewServer overrides it, Close restores it.
+// Tests using a Server should hence not run in parallel and should
+// Quit any slk.Slk connected to it before closing the Server.
+package slktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+	"golang.org/x/net/websocket"
+)
+
+// Token is the token the Server expects, pass it to slk.NewSlk.
+const Token = "xoxp-slktest"
+
+// Handler handles a single web api method, the returned value is json
+// encoded and merged with {"ok": true} unless it contains an "ok" key.
+type Handler func(values url.Values) map[string]interface{}
+
+// Call is a single web api or rtm call the Server received.
+type Call struct {
+	Method string
+	Values url.Values
+}
+
+// Server is a fake slack web api (rtm.start, channels.history,
+// chat.postMessage, ...) and rtm websocket endpoint.
+type Server struct {
+	// URL of the web api, e.g.: http://127.0.0.1:1234/api/
+	URL string
+
+	srv     *httptest.Server
+	prevAPI string
+
+	mutex    sync.Mutex
+	self     *slack.UserDetails
+	users    []slack.User
+	expires  map[string]int64
+	channels []slack.Channel
+	groups   []slack.Group
+	ims      []slack.IM
+	ugroups  []map[string]interface{}
+	history  map[string][]slack.Message
+	pins     map[string][]slack.Item
+	stars    []slack.Item
+	files    []slack.File
+	emoji    map[string]string
+	dnd      map[string]slack.DNDStatus
+	prefs    map[string]string
+	remind   []map[string]interface{}
+	handlers map[string]Handler
+	calls    []Call
+	lastTs   int64
+	conns    map[*websocket.Conn]*conn
+}
+
+type conn struct {
+	events chan []byte
+	done   chan struct{}
+}
+
+type byTs []slack.Message
+
+func (a byTs) Len() int      { return len(a) }
+func (a byTs) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byTs) Less(i, j int) bool {
+	return parseTs(a[i].Timestamp) < parseTs(a[j].Timestamp)
+}
+
+func parseTs(ts string) float64 {
+	f, _ := strconv.ParseFloat(ts, 64)
+	return f
+}
+
+// NewServer starts a Server and points the nlopes/slack client to it.
+// The given user id and name will be returned as the authenticated user.
+func NewServer(selfID, selfName string) *Server {
+	s := &Server{
+		self:     &slack.UserDetails{ID: selfID, Name: selfName},
+		history:  make(map[string][]slack.Message),
+		pins:     make(map[string][]slack.Item),
+		emoji:    make(map[string]string),
+		expires:  make(map[string]int64),
+		dnd:      make(map[string]slack.DNDStatus),
+		prefs:    make(map[string]string),
+		handlers: make(map[string]Handler),
+		lastTs:   time.Now().Unix(),
+		conns:    make(map[*websocket.Conn]*conn),
+	}
+
+	s.AddUser(selfID, selfName)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", s.api)
+	mux.Handle("/ws", websocket.Server{Handler: s.ws})
+	s.srv = httptest.NewServer(mux)
+	s.URL = s.srv.URL + "/api/"
+
+	s.prevAPI = slack.SLACK_API
+	slack.SLACK_API = s.URL
+
+	s.defaultHandlers()
+
+	return s
+}
+
+// Close stops the Server and restores slack.SLACK_API.
+func (s *Server) Close() {
+	s.mutex.Lock()
+	for ws := range s.conns {
+		ws.Close()
+	}
+	s.mutex.Unlock()
+
+	s.srv.Close()
+	slack.SLACK_API = s.prevAPI
+}
+
+// Handle registers (or overrides) the handler for the given web api method.
+func (s *Server) Handle(method string, handler Handler) {
+	s.mutex.Lock()
+	s.handlers[method] = handler
+	s.mutex.Unlock()
+}
+
+// Calls returns all received calls to the given web api method.
+// rtm messages sent by the client are recorded as "rtm.<type>".
+func (s *Server) Calls(method string) []Call {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	calls := make([]Call, 0)
+	for i := range s.calls {
+		if s.calls[i].Method == method {
+			calls = append(calls, s.calls[i])
+		}
+	}
+
+	return calls
+}
+
+// Ts returns a new, unique slack timestamp.
+func (s *Server) Ts() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.ts()
+}
+
+func (s *Server) ts() string {
+	s.lastTs++
+	return fmt.Sprintf("%d.%06d", s.lastTs, s.lastTs%1000000)
+}
+
+// AddUser adds a user to the team.
+func (s *Server) AddUser(id, name string) {
+	s.mutex.Lock()
+	s.users = append(
+		s.users,
+		slack.User{ID: id, Name: name, Presence: "active"},
+	)
+	s.mutex.Unlock()
+}
+
+// AddChannel adds a public channel.
+func (s *Server) AddChannel(id, name string, isMember bool, members ...string) {
+	c := slack.Channel{IsChannel: true, IsMember: isMember}
+	c.ID = id
+	c.Name = name
+	c.Members = members
+	s.mutex.Lock()
+	s.channels = append(s.channels, c)
+	s.mutex.Unlock()
+}
+
+// SetMembers replaces the members of a channel or private channel without
+// sending any events.
+func (s *Server) SetMembers(channel string, members ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range s.channels {
+		if s.channels[i].ID == channel {
+			s.channels[i].Members = members
+		}
+	}
+
+	for i := range s.groups {
+		if s.groups[i].ID == channel {
+			s.groups[i].Members = members
+		}
+	}
+}
+
+// AddGroup adds a private channel.
+func (s *Server) AddGroup(id, name string, members ...string) {
+	g := slack.Group{IsGroup: true}
+	g.ID = id
+	g.Name = name
+	g.Members = members
+	s.mutex.Lock()
+	s.groups = append(s.groups, g)
+	s.mutex.Unlock()
+}
+
+// AddMPIM adds an open multi-party im with ourselves and the given users.
+func (s *Server) AddMPIM(id string, users ...string) {
+	s.mutex.Lock()
+	s.addMPIM(id, users)
+	s.mutex.Unlock()
+}
+
+func (s *Server) addMPIM(id string, users []string) slack.Group {
+	members := append([]string{s.self.ID}, users...)
+	names := make([]string, 0, len(members))
+	for _, m := range members {
+		for _, u := range s.users {
+			if u.ID == m {
+				names = append(names, u.Name)
+			}
+		}
+
+		if m == s.self.ID {
+			names = append(names, s.self.Name)
+		}
+	}
+
+	g := slack.Group{}
+	g.ID = id
+	g.Name = "mpdm-" + strings.Join(names, "--") + "-1"
+	g.Members = members
+	g.IsOpen = true
+	s.groups = append(s.groups, g)
+	return g
+}
+
+// SetStatus sets the custom status of the given user and sends a
+// user_change event.
+func (s *Server) SetStatus(user, emoji, text string) error {
+	return s.SetStatusExpiration(user, emoji, text, 0)
+}
+
+// SetStatusExpiration sets the custom status of the given user that
+// expires at the given unix time and sends a user_change event.
+func (s *Server) SetStatusExpiration(
+	user,
+	emoji,
+	text string,
+	expiration int64,
+) error {
+	s.mutex.Lock()
+	var u map[string]interface{}
+	s.expires[user] = expiration
+	for i := range s.users {
+		if s.users[i].ID == user {
+			s.users[i].Profile.StatusEmoji = emoji
+			s.users[i].Profile.StatusText = text
+			u = s.user(s.users[i])
+		}
+	}
+	s.mutex.Unlock()
+
+	// Like slack, do not include the presence.
+	delete(u, "presence")
+	return s.Send(map[string]interface{}{"type": "user_change", "user": u})
+}
+
+// user returns the json object of u including its status expiration,
+// which slack.UserProfile lacks.
+func (s *Server) user(u slack.User) map[string]interface{} {
+	var m map[string]interface{}
+	raw, _ := json.Marshal(u)
+	json.Unmarshal(raw, &m)
+	if p, ok := m["profile"].(map[string]interface{}); ok {
+		p["status_expiration"] = s.expires[u.ID]
+	}
+
+	return m
+}
+
+// SetDND sets the do not disturb status of the given user and sends a
+// dnd_updated(_user) event.
+func (s *Server) SetDND(user string, status slack.DNDStatus) error {
+	s.mutex.Lock()
+	s.dnd[user] = status
+	typ := "dnd_updated_user"
+	if user == s.self.ID {
+		typ = "dnd_updated"
+	}
+	s.mutex.Unlock()
+
+	return s.Send(
+		map[string]interface{}{
+			"type":       typ,
+			"user":       user,
+			"dnd_status": status,
+		},
+	)
+}
+
+// SetPref sets one of our slack preferences and sends a pref_change event.
+func (s *Server) SetPref(name, value string) error {
+	s.mutex.Lock()
+	s.prefs[name] = value
+	s.mutex.Unlock()
+
+	return s.Send(
+		map[string]interface{}{
+			"type":  "pref_change",
+			"name":  name,
+			"value": value,
+		},
+	)
+}
+
+// AddUserGroup adds a user group with the given members.
+func (s *Server) AddUserGroup(id, handle string, members ...string) {
+	s.mutex.Lock()
+	s.ugroups = append(
+		s.ugroups,
+		map[string]interface{}{
+			"id":     id,
+			"handle": handle,
+			"name":   handle,
+			"users":  members,
+		},
+	)
+	s.mutex.Unlock()
+}
+
+// SetLastRead sets the timestamp of the last read message of a channel,
+// private channel or im without sending any events.
+func (s *Server) SetLastRead(channel, ts string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range s.channels {
+		if s.channels[i].ID == channel {
+			s.channels[i].LastRead = ts
+		}
+	}
+
+	for i := range s.groups {
+		if s.groups[i].ID == channel {
+			s.groups[i].LastRead = ts
+		}
+	}
+
+	for i := range s.ims {
+		if s.ims[i].ID == channel {
+			s.ims[i].LastRead = ts
+		}
+	}
+}
+
+// AddIM adds an IM channel with the given user.
+func (s *Server) AddIM(id, user string) {
+	im := slack.IM{IsIM: true, User: user}
+	im.ID = id
+	im.IsOpen = true
+	s.mutex.Lock()
+	s.ims = append(s.ims, im)
+	s.mutex.Unlock()
+}
+
+// AddMessage adds a message to the history of the given channel and returns
+// its timestamp.
+func (s *Server) AddMessage(channel, user, text string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addMessage(slack.Msg{Channel: channel, User: user, Text: text})
+}
+
+func (s *Server) addMessage(msg slack.Msg) string {
+	if msg.Timestamp == "" {
+		msg.Timestamp = s.ts()
+	}
+
+	msg.Type = "message"
+	s.history[msg.Channel] = append(
+		s.history[msg.Channel],
+		slack.Message{Msg: msg},
+	)
+
+	return msg.Timestamp
+}
+
+// AddPin pins the message with the given timestamp.
+func (s *Server) AddPin(channel, ts string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, m := range s.history[channel] {
+		if m.Timestamp == ts {
+			msg := m
+			s.pins[channel] = append(
+				s.pins[channel],
+				slack.Item{Type: "message", Channel: channel, Message: &msg},
+			)
+		}
+	}
+}
+
+// AddEmoji adds a custom emoji and sends an emoji_changed event.
+func (s *Server) AddEmoji(name, url string) {
+	s.mutex.Lock()
+	s.emoji[name] = url
+	s.mutex.Unlock()
+
+	s.Send(
+		map[string]interface{}{
+			"type":     "emoji_changed",
+			"subtype":  "add",
+			"name":     name,
+			"value":    url,
+			"event_ts": s.Ts(),
+		},
+	)
+}
+
+// AddFile adds a file, shared in the given channels.
+func (s *Server) AddFile(id, user, name string, channels ...string) {
+	s.mutex.Lock()
+	s.files = append(
+		s.files,
+		slack.File{
+			ID:         id,
+			User:       user,
+			Name:       name,
+			Title:      name,
+			Channels:   channels,
+			URLPrivate: s.srv.URL + "/files/" + id,
+			Timestamp:  slack.JSONTime(time.Now().Unix()),
+		},
+	)
+	s.mutex.Unlock()
+}
+
+// Send writes the given event to all connected rtm clients.
+// event is json encoded, e.g.: a map[string]interface{} or a slack.*Event.
+func (s *Server) Send(event interface{}) error {
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, c := range s.conns {
+		select {
+		case c.events <- raw:
+		case <-c.done:
+		}
+	}
+
+	return nil
+}
+
+// SendMessage adds a message to the history of the given channel and
+// sends it as a rtm message event. The timestamp is returned.
+func (s *Server) SendMessage(channel, user, text string) string {
+	s.mutex.Lock()
+	ts := s.addMessage(slack.Msg{Channel: channel, User: user, Text: text})
+	s.mutex.Unlock()
+
+	s.Send(
+		map[string]interface{}{
+			"type":    "message",
+			"channel": channel,
+			"user":    user,
+			"text":    text,
+			"ts":      ts,
+		},
+	)
+
+	return ts
+}
+
+// Connected returns the amount of connected rtm clients.
+func (s *Server) Connected() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.conns)
+}
+
+func (s *Server) api(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil &&
+		err != http.ErrNotMultipart {
+		r.ParseForm()
+	}
+
+	method := strings.TrimPrefix(r.URL.Path, "/api/")
+	values := url.Values{}
+	for k, v := range r.Form {
+		values[k] = v
+	}
+
+	s.mutex.Lock()
+	s.calls = append(s.calls, Call{method, values})
+	handler := s.handlers[method]
+	s.mutex.Unlock()
+
+	res := map[string]interface{}{"ok": true}
+	if values.Get("token") != Token {
+		res = map[string]interface{}{"ok": false, "error": "invalid_auth"}
+	} else if handler != nil {
+		data := handler(values)
+		for k := range data {
+			res[k] = data[k]
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func (s *Server) ws(ws *websocket.Conn) {
+	c := &conn{make(chan []byte, 50), make(chan struct{})}
+	s.mutex.Lock()
+	s.conns[ws] = c
+	s.mutex.Unlock()
+
+	defer func() {
+		close(c.done)
+		s.mutex.Lock()
+		delete(s.conns, ws)
+		s.mutex.Unlock()
+		ws.Close()
+	}()
+
+	go func() {
+		for {
+			select {
+			case raw := <-c.events:
+				if _, err := ws.Write(raw); err != nil {
+					ws.Close()
+					return
+				}
+			case <-c.done:
+				return
+			}
+		}
+	}()
+
+	c.events <- []byte(`{"type":"hello"}`)
+
+	for {
+		var msg map[string]interface{}
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			return
+		}
+
+		typ, _ := msg["type"].(string)
+		id, _ := msg["id"].(float64)
+		values := url.Values{}
+		for k, v := range msg {
+			values.Set(k, fmt.Sprint(v))
+		}
+
+		s.mutex.Lock()
+		s.calls = append(s.calls, Call{"rtm." + typ, values})
+		s.mutex.Unlock()
+
+		switch typ {
+		case "ping":
+			c.events <- []byte(fmt.Sprintf(`{"type":"pong","reply_to":%d}`, int(id)))
+		}
+	}
+}
+
+func (s *Server) defaultHandlers() {
+	s.handlers["rtm.start"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return map[string]interface{}{
+			"url":      strings.Replace(s.srv.URL, "http://", "ws://", 1) + "/ws",
+			"self":     s.self,
+			"team":     &slack.Team{ID: "T0", Name: "slktest", Domain: "slktest"},
+			"users":    s.users,
+			"channels": s.channels,
+			"groups":   s.groups,
+			"ims":      s.ims,
+		}
+	}
+
+	s.handlers["users.list"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		users := make([]map[string]interface{}, len(s.users))
+		for i := range s.users {
+			users[i] = s.user(s.users[i])
+		}
+
+		return map[string]interface{}{"members": users}
+	}
+
+	s.handlers["users.info"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		for i := range s.users {
+			if s.users[i].ID == v.Get("user") {
+				return map[string]interface{}{"user": s.user(s.users[i])}
+			}
+		}
+
+		return map[string]interface{}{"ok": false, "error": "user_not_found"}
+	}
+
+	s.handlers["channels.list"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return map[string]interface{}{"channels": s.channels}
+	}
+
+	s.handlers["groups.list"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return map[string]interface{}{"groups": s.groups}
+	}
+
+	s.handlers["im.list"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return map[string]interface{}{"ims": s.ims}
+	}
+
+	s.handlers["usergroups.list"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return map[string]interface{}{"usergroups": s.ugroups}
+	}
+
+	s.handlers["channels.history"] = s.historyHandler
+	s.handlers["groups.history"] = s.historyHandler
+	s.handlers["im.history"] = s.historyHandler
+
+	s.handlers["mpim.history"] = s.historyHandler
+
+	s.handlers["im.open"] = func(v url.Values) map[string]interface{} {
+		user := v.Get("user")
+		s.mutex.Lock()
+		for _, im := range s.ims {
+			if im.User == user {
+				s.mutex.Unlock()
+				return map[string]interface{}{
+					"channel":      map[string]string{"id": im.ID},
+					"already_open": true,
+				}
+			}
+		}
+
+		id := fmt.Sprintf("D%d", len(s.ims)+100)
+		s.mutex.Unlock()
+		s.AddIM(id, user)
+		return map[string]interface{}{"channel": map[string]string{"id": id}}
+	}
+
+	s.handlers["users.profile.set"] = func(v url.Values) map[string]interface{} {
+		var profile struct {
+			slack.UserProfile
+			StatusExpiration int64 `json:"status_expiration"`
+		}
+		if err := json.Unmarshal([]byte(v.Get("profile")), &profile); err != nil {
+			return map[string]interface{}{"ok": false, "error": "invalid_profile"}
+		}
+
+		s.SetStatusExpiration(
+			s.self.ID,
+			profile.StatusEmoji,
+			profile.StatusText,
+			profile.StatusExpiration,
+		)
+		return map[string]interface{}{"profile": profile}
+	}
+
+	s.handlers["channels.create"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		id := fmt.Sprintf("C%d", len(s.channels)+100)
+		s.mutex.Unlock()
+		s.AddChannel(id, v.Get("name"), true, s.self.ID)
+		return map[string]interface{}{"channel": s.channelByID(id)}
+	}
+
+	s.handlers["groups.create"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		id := fmt.Sprintf("G%d", len(s.groups)+100)
+		s.mutex.Unlock()
+		s.AddGroup(id, v.Get("name"), s.self.ID)
+		return map[string]interface{}{"group": s.groupByID(id)}
+	}
+
+	for _, action := range []string{"rename", "archive", "unarchive", "kick"} {
+		s.handlers["channels."+action] = s.adminHandler(action)
+		s.handlers["groups."+action] = s.adminHandler(action)
+	}
+
+	s.handlers["channels.setTopic"] = s.infoHandler("channel", "topic")
+	s.handlers["groups.setTopic"] = s.infoHandler("group", "topic")
+	s.handlers["channels.setPurpose"] = s.infoHandler("channel", "purpose")
+	s.handlers["groups.setPurpose"] = s.infoHandler("group", "purpose")
+
+	s.handlers["reminders.add"] = func(v url.Values) map[string]interface{} {
+		t, _ := strconv.ParseInt(v.Get("time"), 10, 64)
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		user := v.Get("user")
+		if user == "" {
+			user = s.self.ID
+		}
+
+		r := map[string]interface{}{
+			"id":          fmt.Sprintf("Rm%d", len(s.remind)+1),
+			"creator":     s.self.ID,
+			"user":        user,
+			"text":        v.Get("text"),
+			"time":        t,
+			"complete_ts": 0,
+		}
+		s.remind = append(s.remind, r)
+		return map[string]interface{}{"reminder": r}
+	}
+
+	s.handlers["reminders.list"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return map[string]interface{}{"reminders": s.remind}
+	}
+
+	s.handlers["reminders.complete"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		for _, r := range s.remind {
+			if r["id"] == v.Get("reminder") {
+				r["complete_ts"] = time.Now().Unix()
+				return nil
+			}
+		}
+
+		return map[string]interface{}{"ok": false, "error": "not_found"}
+	}
+
+	s.handlers["reminders.delete"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		for i, r := range s.remind {
+			if r["id"] == v.Get("reminder") {
+				s.remind = append(s.remind[:i], s.remind[i+1:]...)
+				return nil
+			}
+		}
+
+		return map[string]interface{}{"ok": false, "error": "not_found"}
+	}
+
+	s.handlers["users.prefs.get"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		prefs := make(map[string]string, len(s.prefs))
+		for name, value := range s.prefs {
+			prefs[name] = value
+		}
+
+		return map[string]interface{}{"prefs": prefs}
+	}
+
+	s.handlers["dnd.teamInfo"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		users := make(map[string]slack.DNDStatus)
+		for _, id := range strings.Split(v.Get("users"), ",") {
+			users[id] = s.dnd[id]
+		}
+
+		return map[string]interface{}{"users": users}
+	}
+
+	s.handlers["dnd.info"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return dndResponse(s.dnd[s.self.ID])
+	}
+
+	s.handlers["dnd.setSnooze"] = func(v url.Values) map[string]interface{} {
+		minutes, _ := strconv.Atoi(v.Get("num_minutes"))
+		s.mutex.Lock()
+		st := s.dnd[s.self.ID]
+		s.mutex.Unlock()
+		st.SnoozeEnabled = true
+		st.SnoozeEndTime = int(time.Now().Unix()) + minutes*60
+		st.SnoozeRemaining = minutes * 60
+		s.SetDND(s.self.ID, st)
+		return dndResponse(st)
+	}
+
+	s.handlers["dnd.endSnooze"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		st := s.dnd[s.self.ID]
+		s.mutex.Unlock()
+		st.SnoozeInfo = slack.SnoozeInfo{}
+		s.SetDND(s.self.ID, st)
+		return dndResponse(st)
+	}
+
+	s.handlers["dnd.endDnd"] = func(v url.Values) map[string]interface{} {
+		s.SetDND(s.self.ID, slack.DNDStatus{})
+		return nil
+	}
+
+	s.handlers["mpim.open"] = func(v url.Values) map[string]interface{} {
+		users := strings.Split(v.Get("users"), ",")
+		sort.Strings(users)
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		for _, g := range s.groups {
+			members := make([]string, 0, len(g.Members))
+			for _, m := range g.Members {
+				if m != s.self.ID {
+					members = append(members, m)
+				}
+			}
+
+			sort.Strings(members)
+			if strings.HasPrefix(g.Name, "mpdm-") &&
+				strings.Join(members, ",") == strings.Join(users, ",") {
+				return map[string]interface{}{"group": g}
+			}
+		}
+
+		g := s.addMPIM(fmt.Sprintf("G%d", len(s.groups)+1), users)
+		return map[string]interface{}{"group": g}
+	}
+
+	s.handlers["chat.postMessage"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		channel := v.Get("channel")
+		for _, im := range s.ims {
+			if im.User == channel {
+				channel = im.ID
+			}
+		}
+
+		msg := slack.Msg{
+			Channel:         channel,
+			User:            s.self.ID,
+			Text:            v.Get("text"),
+			ThreadTimestamp: v.Get("thread_ts"),
+		}
+		msg.Timestamp = s.addMessage(msg)
+		s.mutex.Unlock()
+
+		s.Send(
+			map[string]interface{}{
+				"type":      "message",
+				"channel":   msg.Channel,
+				"user":      msg.User,
+				"text":      msg.Text,
+				"ts":        msg.Timestamp,
+				"thread_ts": msg.ThreadTimestamp,
+			},
+		)
+
+		return map[string]interface{}{
+			"channel": msg.Channel,
+			"ts":      msg.Timestamp,
+			"text":    msg.Text,
+		}
+	}
+
+	s.handlers["chat.update"] = func(v url.Values) map[string]interface{} {
+		channel, ts, text := v.Get("channel"), v.Get("ts"), v.Get("text")
+		s.mutex.Lock()
+		var msg *slack.Msg
+		for i := range s.history[channel] {
+			if s.history[channel][i].Timestamp == ts {
+				msg = &s.history[channel][i].Msg
+			}
+		}
+
+		if msg == nil {
+			s.mutex.Unlock()
+			return map[string]interface{}{"ok": false, "error": "message_not_found"}
+		}
+
+		msg.Text = text
+		msg.Edited = &slack.Edited{User: s.self.ID, Timestamp: s.ts()}
+		event := map[string]interface{}{
+			"type":    "message",
+			"subtype": "message_changed",
+			"hidden":  true,
+			"channel": channel,
+			"ts":      msg.Edited.Timestamp,
+			"message": *msg,
+		}
+		s.mutex.Unlock()
+
+		s.Send(event)
+
+		return map[string]interface{}{"channel": channel, "ts": ts, "text": text}
+	}
+
+	s.handlers["chat.delete"] = func(v url.Values) map[string]interface{} {
+		channel, ts := v.Get("channel"), v.Get("ts")
+		s.mutex.Lock()
+		found := false
+		msgs := s.history[channel]
+		for i := range msgs {
+			if msgs[i].Timestamp == ts {
+				s.history[channel] = append(msgs[:i:i], msgs[i+1:]...)
+				found = true
+				break
+			}
+		}
+		s.mutex.Unlock()
+
+		if !found {
+			return map[string]interface{}{"ok": false, "error": "message_not_found"}
+		}
+
+		s.Send(
+			map[string]interface{}{
+				"type":       "message",
+				"subtype":    "message_deleted",
+				"hidden":     true,
+				"channel":    channel,
+				"ts":         s.Ts(),
+				"deleted_ts": ts,
+			},
+		)
+
+		return map[string]interface{}{"channel": channel, "ts": ts}
+	}
+
+	s.handlers["conversations.replies"] = s.repliesHandler
+	s.handlers["conversations.members"] = s.membersHandler
+
+	s.handlers["emoji.list"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return map[string]interface{}{"emoji": s.emoji}
+	}
+
+	s.handlers["reactions.add"] = s.reactionHandler("reaction_added")
+	s.handlers["reactions.remove"] = s.reactionHandler("reaction_removed")
+
+	s.handlers["search.messages"] = s.searchHandler
+
+	s.handlers["pins.add"] = s.pinHandler("pin_added")
+	s.handlers["pins.remove"] = s.pinHandler("pin_removed")
+
+	s.handlers["stars.add"] = s.starHandler(true)
+	s.handlers["stars.remove"] = s.starHandler(false)
+	s.handlers["stars.list"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		count, _ := strconv.Atoi(v.Get("count"))
+		page, _ := strconv.Atoi(v.Get("page"))
+		if count == 0 {
+			count = 100
+		}
+		if page == 0 {
+			page = 1
+		}
+
+		total := len(s.stars)
+		start := (page - 1) * count
+		if start > total {
+			start = total
+		}
+
+		end := start + count
+		if end > total {
+			end = total
+		}
+
+		return map[string]interface{}{
+			"items": s.stars[start:end],
+			"paging": slack.Paging{
+				Count: count,
+				Total: total,
+				Page:  page,
+				Pages: (total + count - 1) / count,
+			},
+		}
+	}
+
+	s.handlers["pins.list"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		items := s.pins[v.Get("channel")]
+		if items == nil {
+			items = []slack.Item{}
+		}
+
+		return map[string]interface{}{"items": items}
+	}
+
+	s.handlers["files.list"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		files := make([]slack.File, 0, len(s.files))
+		channel := v.Get("channel")
+		for _, f := range s.files {
+			for i := range f.Channels {
+				if channel == "" || f.Channels[i] == channel {
+					files = append(files, f)
+					break
+				}
+			}
+		}
+
+		return map[string]interface{}{
+			"files":  files,
+			"paging": slack.Paging{Count: len(files), Total: len(files), Page: 1},
+		}
+	}
+
+	s.handlers["files.upload"] = func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		f := slack.File{
+			ID:        fmt.Sprintf("F%d", len(s.files)),
+			User:      s.self.ID,
+			Name:      v.Get("filename"),
+			Title:     v.Get("title"),
+			Channels:  strings.Split(v.Get("channels"), ","),
+			Timestamp: slack.JSONTime(time.Now().Unix()),
+		}
+		s.files = append(s.files, f)
+
+		return map[string]interface{}{"file": f}
+	}
+}
+
+// AddReply adds a reply to the thread of the given message and returns
+// its timestamp.
+func (s *Server) AddReply(channel, thread, user, text string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addMessage(
+		slack.Msg{
+			Channel:         channel,
+			User:            user,
+			Text:            text,
+			ThreadTimestamp: thread,
+		},
+	)
+}
+
+// searchHandler matches messages containing all words of the query,
+// an in:#channel or in:@user modifier limits the search to that room.
+func (s *Server) channelByID(id string) slack.Channel {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, c := range s.channels {
+		if c.ID == id {
+			return c
+		}
+	}
+
+	return slack.Channel{}
+}
+
+func (s *Server) groupByID(id string) slack.Group {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, g := range s.groups {
+		if g.ID == id {
+			return g
+		}
+	}
+
+	return slack.Group{}
+}
+
+// adminHandler handles {channels,groups}.{rename,archive,unarchive,kick}.
+func (s *Server) adminHandler(action string) Handler {
+	return func(v url.Values) map[string]interface{} {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		id := v.Get("channel")
+		for i := range s.channels {
+			if s.channels[i].ID == id {
+				c := &s.channels[i]
+				admin(action, v, &c.Name, &c.IsArchived, &c.Members)
+				return map[string]interface{}{"channel": c}
+			}
+		}
+
+		for i := range s.groups {
+			if s.groups[i].ID == id {
+				g := &s.groups[i]
+				admin(action, v, &g.Name, &g.IsArchived, &g.Members)
+				return map[string]interface{}{"channel": g, "group": g}
+			}
+		}
+
+		return map[string]interface{}{"ok": false, "error": "channel_not_found"}
+	}
+}
+
+func admin(
+	action string,
+	v url.Values,
+	name *string,
+	archived *bool,
+	members *[]string,
+) {
+	switch action {
+	case "rename":
+		*name = v.Get("name")
+	case "archive":
+		*archived = true
+	case "unarchive":
+		*archived = false
+	case "kick":
+		for i := range *members {
+			if (*members)[i] == v.Get("user") {
+				*members = append((*members)[:i], (*members)[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// infoHandler handles {channels,groups}.set{Topic,Purpose}.
+func (s *Server) infoHandler(typ, field string) Handler {
+	return func(v url.Values) map[string]interface{} {
+		channel, value := v.Get("channel"), v.Get(field)
+		s.mutex.Lock()
+		for i := range s.channels {
+			if s.channels[i].ID == channel && field == "topic" {
+				s.channels[i].Topic.Value = value
+			} else if s.channels[i].ID == channel {
+				s.channels[i].Purpose.Value = value
+			}
+		}
+
+		for i := range s.groups {
+			if s.groups[i].ID == channel && field == "topic" {
+				s.groups[i].Topic.Value = value
+			} else if s.groups[i].ID == channel {
+				s.groups[i].Purpose.Value = value
+			}
+		}
+
+		msg := slack.Msg{
+			Channel: channel,
+			User:    s.self.ID,
+			SubType: typ + "_" + field,
+			Text:    fmt.Sprintf("set the %s %s: %s", typ, field, value),
+			Topic:   value,
+		}
+		if field == "purpose" {
+			msg.Topic, msg.Purpose = "", value
+		}
+
+		msg.Timestamp = s.addMessage(msg)
+		s.mutex.Unlock()
+
+		s.Send(
+			map[string]interface{}{
+				"type":    "message",
+				"subtype": msg.SubType,
+				"channel": msg.Channel,
+				"user":    msg.User,
+				"text":    msg.Text,
+				"ts":      msg.Timestamp,
+				field:     value,
+			},
+		)
+
+		return map[string]interface{}{field: value}
+	}
+}
+
+func dndResponse(st slack.DNDStatus) map[string]interface{} {
+	return map[string]interface{}{
+		"dnd_enabled":       st.Enabled,
+		"next_dnd_start_ts": st.NextStartTimestamp,
+		"next_dnd_end_ts":   st.NextEndTimestamp,
+		"snooze_enabled":    st.SnoozeEnabled,
+		"snooze_endtime":    st.SnoozeEndTime,
+		"snooze_remaining":  st.SnoozeRemaining,
+	}
+}
+
+func (s *Server) searchHandler(v url.Values) map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count, _ := strconv.Atoi(v.Get("count"))
+	page, _ := strconv.Atoi(v.Get("page"))
+	if count == 0 {
+		count = 100
+	}
+	if page == 0 {
+		page = 1
+	}
+
+	names := make(map[string]string)
+	for _, c := range s.channels {
+		names[c.ID] = "#" + c.Name
+	}
+	for _, g := range s.groups {
+		names[g.ID] = "#" + g.Name
+	}
+	for _, im := range s.ims {
+		for _, u := range s.users {
+			if u.ID == im.User {
+				names[im.ID] = "@" + u.Name
+			}
+		}
+	}
+
+	var in string
+	words := make([]string, 0)
+	for _, w := range strings.Fields(v.Get("query")) {
+		if strings.HasPrefix(w, "in:") {
+			in = w[3:]
+			continue
+		}
+
+		words = append(words, w)
+	}
+
+	matches := make([]slack.SearchMessage, 0)
+	for channel, msgs := range s.history {
+		if in != "" && names[channel] != in {
+			continue
+		}
+
+	messages:
+		for _, m := range msgs {
+			for _, w := range words {
+				if !strings.Contains(m.Text, w) {
+					continue messages
+				}
+			}
+
+			matches = append(
+				matches,
+				slack.SearchMessage{
+					Type: "message",
+					Channel: slack.CtxChannel{
+						ID:   channel,
+						Name: strings.TrimLeft(names[channel], "#@"),
+					},
+					User:      m.User,
+					Timestamp: m.Timestamp,
+					Text:      m.Text,
+				},
+			)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return parseTs(matches[i].Timestamp) > parseTs(matches[j].Timestamp)
+	})
+
+	total := len(matches)
+	pages := (total + count - 1) / count
+	start := (page - 1) * count
+	if start > total {
+		start = total
+	}
+
+	end := start + count
+	if end > total {
+		end = total
+	}
+
+	return map[string]interface{}{
+		"query": v.Get("query"),
+		"messages": map[string]interface{}{
+			"matches": matches[start:end],
+			"total":   total,
+			"paging": slack.Paging{
+				Count: count,
+				Total: total,
+				Page:  page,
+				Pages: pages,
+			},
+		},
+	}
+}
+
+// starHandler stars or unstars a message.
+func (s *Server) starHandler(add bool) Handler {
+	return func(v url.Values) map[string]interface{} {
+		channel, ts := v.Get("channel"), v.Get("timestamp")
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		stars := make([]slack.Item, 0, len(s.stars)+1)
+		for _, i := range s.stars {
+			if i.Channel != channel || i.Message.Timestamp != ts {
+				stars = append(stars, i)
+			}
+		}
+
+		if add {
+			for i := range s.history[channel] {
+				if s.history[channel][i].Timestamp == ts {
+					m := s.history[channel][i]
+					stars = append(
+						stars,
+						slack.Item{Type: "message", Channel: channel, Message: &m},
+					)
+				}
+			}
+
+			if len(stars) == len(s.stars) {
+				return map[string]interface{}{"ok": false, "error": "message_not_found"}
+			}
+		}
+
+		s.stars = stars
+		return map[string]interface{}{}
+	}
+}
+
+// pinHandler adds or removes a pin and sends a pin event of the given type.
+func (s *Server) pinHandler(typ string) Handler {
+	return func(v url.Values) map[string]interface{} {
+		channel, ts := v.Get("channel"), v.Get("timestamp")
+		s.mutex.Lock()
+		var msg *slack.Message
+		for i := range s.history[channel] {
+			if s.history[channel][i].Timestamp == ts {
+				m := s.history[channel][i]
+				msg = &m
+			}
+		}
+
+		if msg == nil {
+			s.mutex.Unlock()
+			return map[string]interface{}{"ok": false, "error": "message_not_found"}
+		}
+
+		pins := make([]slack.Item, 0, len(s.pins[channel])+1)
+		for _, p := range s.pins[channel] {
+			if p.Message == nil || p.Message.Timestamp != ts {
+				pins = append(pins, p)
+			}
+		}
+
+		item := slack.Item{Type: "message", Channel: channel, Message: msg}
+		if typ == "pin_added" {
+			pins = append(pins, item)
+		}
+
+		s.pins[channel] = pins
+		user := s.self.ID
+		s.mutex.Unlock()
+
+		s.Send(
+			map[string]interface{}{
+				"type":       typ,
+				"user":       user,
+				"channel_id": channel,
+				"item":       item,
+				"event_ts":   s.Ts(),
+			},
+		)
+
+		return map[string]interface{}{}
+	}
+}
+
+// standardEmoji are the emoji names the Server accepts besides the custom
+// ones added with AddEmoji.
+var standardEmoji = map[string]bool{
+	"+1":    true,
+	"-1":    true,
+	"smile": true,
+	"eyes":  true,
+	"heart": true,
+	"tada":  true,
+}
+
+// reactionHandler validates the emoji name and sends a reaction event of
+// the given type.
+func (s *Server) reactionHandler(typ string) Handler {
+	return func(v url.Values) map[string]interface{} {
+		name := v.Get("name")
+		s.mutex.Lock()
+		_, custom := s.emoji[name]
+		user := s.self.ID
+		s.mutex.Unlock()
+
+		if !custom && !standardEmoji[name] {
+			return map[string]interface{}{"ok": false, "error": "invalid_name"}
+		}
+
+		s.Send(
+			map[string]interface{}{
+				"type":     typ,
+				"user":     user,
+				"reaction": name,
+				"item": map[string]string{
+					"type":    "message",
+					"channel": v.Get("channel"),
+					"ts":      v.Get("timestamp"),
+				},
+				"event_ts": s.Ts(),
+			},
+		)
+
+		return map[string]interface{}{}
+	}
+}
+
+// repliesHandler returns the thread parent and its replies, the cursor is
+// the offset of the page.
+// membersPageLimit caps the page size of conversations.members so clients
+// have to paginate.
+const membersPageLimit = 100
+
+func (s *Server) membersHandler(v url.Values) map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	limit, _ := strconv.Atoi(v.Get("limit"))
+	offset, _ := strconv.Atoi(v.Get("cursor"))
+	if limit == 0 || limit > membersPageLimit {
+		limit = membersPageLimit
+	}
+
+	var members []string
+	found := false
+	for _, c := range s.channels {
+		if c.ID == v.Get("channel") {
+			members, found = c.Members, true
+		}
+	}
+
+	for _, g := range s.groups {
+		if g.ID == v.Get("channel") {
+			members, found = g.Members, true
+		}
+	}
+
+	if !found {
+		return map[string]interface{}{"ok": false, "error": "channel_not_found"}
+	}
+
+	if offset > len(members) {
+		offset = len(members)
+	}
+
+	members = members[offset:]
+	next := ""
+	if len(members) > limit {
+		members = members[:limit]
+		next = strconv.Itoa(offset + limit)
+	}
+
+	return map[string]interface{}{
+		"members":           members,
+		"response_metadata": map[string]string{"next_cursor": next},
+	}
+}
+
+func (s *Server) repliesHandler(v url.Values) map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	thread := v.Get("ts")
+	limit, _ := strconv.Atoi(v.Get("limit"))
+	offset, _ := strconv.Atoi(v.Get("cursor"))
+	if limit == 0 {
+		limit = 10
+	}
+
+	msgs := make([]slack.Message, 0)
+	for _, m := range s.history[v.Get("channel")] {
+		if m.Timestamp == thread || m.ThreadTimestamp == thread {
+			msgs = append(msgs, m)
+		}
+	}
+
+	if len(msgs) == 0 {
+		return map[string]interface{}{"ok": false, "error": "thread_not_found"}
+	}
+
+	sort.Sort(byTs(msgs))
+	if offset > len(msgs) {
+		offset = len(msgs)
+	}
+
+	msgs = msgs[offset:]
+	next := ""
+	if len(msgs) > limit {
+		msgs = msgs[:limit]
+		next = strconv.Itoa(offset + limit)
+	}
+
+	return map[string]interface{}{
+		"messages":          msgs,
+		"has_more":          next != "",
+		"response_metadata": map[string]string{"next_cursor": next},
+	}
+}
+
+func (s *Server) historyHandler(v url.Values) map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	latest, oldest := parseTs(v.Get("latest")), parseTs(v.Get("oldest"))
+	inclusive := v.Get("inclusive") == "1"
+	count, _ := strconv.Atoi(v.Get("count"))
+	if count == 0 {
+		count = 100
+	}
+
+	msgs := make([]slack.Message, 0)
+	for _, m := range s.history[v.Get("channel")] {
+		if m.ThreadTimestamp != "" && m.ThreadTimestamp != m.Timestamp {
+			// Thread replies are only returned by conversations.replies.
+			continue
+		}
+
+		ts := parseTs(m.Timestamp)
+		if (latest != 0 && (ts > latest || (ts == latest && !inclusive))) ||
+			ts < oldest || (ts == oldest && !inclusive) {
+			continue
+		}
+
+		msgs = append(msgs, m)
+	}
+
+	// Newest first, like slack even if only oldest is given.
+	hasMore := len(msgs) > count
+	sort.Sort(sort.Reverse(byTs(msgs)))
+	if hasMore {
+		msgs = msgs[:count]
+	}
+
+	return map[string]interface{}{
+		"latest":   v.Get("latest"),
+		"messages": msgs,
+		"has_more": hasMore,
+	}
+}
@@ -0,0 +1,39 @@
+package slk
+
+import "github.com/nlopes/slack"
+
+// star stars or unstars the message with the given timestamp.
+func (s *Slk) star(e Entity, ts string, add bool) error {
+	id, err := s.channelID(e)
+	if err != nil {
+		return err
+	}
+
+	ref := slack.NewRefToMessage(id, ts)
+	if add {
+		return s.c.AddStar(id, ref)
+	}
+
+	return s.c.RemoveStar(id, ref)
+}
+
+// starred fetches all pages of your starred items.
+func (s *Slk) starred() ([]slack.Item, error) {
+	p := slack.NewStarsParameters()
+	p.Count = 100
+
+	items := make([]slack.Item, 0)
+	for {
+		page, paging, err := s.c.ListStars(p)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, page...)
+		if paging == nil || paging.Page >= paging.Pages {
+			return items, nil
+		}
+
+		p.Page = paging.Page + 1
+	}
+}
@@ -0,0 +1,76 @@
+package slk_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/frizinak/slek/slk"
+)
+
+func TestStar(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	bob := entity(t, s, slk.TypeUser, "bob")
+	s.Switch(c)
+	srv.SendMessage("C1", "U1", "todo one")
+	srv.SendMessage("D1", "U1", "todo two")
+	out.Wait("Msg", 1, wait)
+	out.Wait("Notify", 1, wait)
+
+	ts, err := s.Recent(c, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imTS, err := s.Recent(bob, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Star(c, ts); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Star(bob, imTS); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	s.Stars()
+	l := out.Wait("List", 1, wait)
+	if len(l) != 1 || len(l[0].Items) != 5 {
+		t.Fatalf("stars: %+v", out.Records(""))
+	}
+
+	found := map[string]bool{}
+	for _, item := range l[0].Items {
+		found[item.Value] = true
+		if i := strings.LastIndex(item.Value, ": "); i != -1 {
+			found[item.Value[:i]] = true
+		}
+	}
+
+	for _, exp := range []string{
+		"Starred items",
+		"todo one",
+		"@bob in #general",
+		"todo two",
+		"@bob in @bob",
+	} {
+		if !found[exp] {
+			t.Errorf("%s not listed: %+v", exp, l[0].Items)
+		}
+	}
+
+	if err := s.Unstar(c, ts); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Stars()
+	if l = out.Wait("List", 2, wait); len(l) != 2 || len(l[1].Items) != 3 {
+		t.Errorf("unstarred: %+v", l)
+	}
+}
@@ -0,0 +1,87 @@
+package slk
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+)
+
+// status returns the custom status emoji and text of the user unless
+// it has expired.
+func (u *user) status() string {
+	if u.statusExpiration != 0 && time.Now().Unix() >= u.statusExpiration {
+		return ""
+	}
+
+	return strings.TrimSpace(u.Profile.StatusEmoji + " " + u.Profile.StatusText)
+}
+
+// nameWithStatus returns the name of e followed by its custom status
+// if e is a user that has one.
+func nameWithStatus(e Entity, name string) string {
+	u, ok := e.(*user)
+	if !ok {
+		return name
+	}
+
+	if status := u.status(); status != "" {
+		return fmt.Sprintf("%-18s %s", name, status)
+	}
+
+	return name
+}
+
+// setStatus sets our custom status, it expires after d unless d is 0.
+func (s *Slk) setStatus(emoji, text string, d time.Duration) error {
+	var expiration int64
+	if d != 0 {
+		expiration = time.Now().Add(d).Unix()
+	}
+
+	return s.c.SetUserCustomStatusWithExpiration(text, emoji, expiration)
+}
+
+// userChanged replaces the user with the updated u.
+func (s *Slk) userChanged(u *slack.User) {
+	usr := &User{User: *u}
+	original := s.user(u.ID)
+	// user_change events lack the status expiration, only fetch it
+	// when the status changed.
+	switch {
+	case original.Profile.StatusEmoji == u.Profile.StatusEmoji &&
+		original.Profile.StatusText == u.Profile.StatusText:
+		usr.StatusExpiration = original.statusExpiration
+	case u.Profile.StatusEmoji != "" || u.Profile.StatusText != "":
+		info, err := s.c.GetUserInfo(u.ID)
+		if err != nil {
+			s.out.Debug("users.info", err.Error())
+			break
+		}
+
+		usr.StatusExpiration = info.StatusExpiration
+	}
+
+	s.updateRegistry(func(reg *registry) {
+		original := reg.user(u.ID)
+		users := make(map[string]*user, len(reg.users)+1)
+		usersByName := make(map[string]*user, len(reg.usersByName)+1)
+		for id := range reg.users {
+			users[id] = reg.users[id]
+		}
+
+		for name := range reg.usersByName {
+			if name != original.Name() {
+				usersByName[name] = reg.usersByName[name]
+			}
+		}
+
+		n := slackUserToUser(usr, original)
+		users[u.ID] = n
+		usersByName[u.Name] = n
+
+		reg.users = users
+		reg.usersByName = usersByName
+	})
+}
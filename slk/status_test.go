@@ -0,0 +1,93 @@
+package slk_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frizinak/slek/slk"
+	"github.com/frizinak/slek/slk/slktest"
+)
+
+// bobListed returns bob's item in the user list.
+func bobListed(t *testing.T, out *slktest.Output, s *slk.Slk) string {
+	out.Reset()
+	s.List(slk.TypeUser, false)
+	l := out.Wait("List", 1, wait)
+	if len(l) != 1 {
+		t.Fatalf("list: %+v", out.Records(""))
+	}
+
+	for _, item := range l[0].Items {
+		if strings.HasPrefix(item.Value, "bob") {
+			return item.Value
+		}
+	}
+
+	t.Fatalf("bob not listed: %+v", l[0].Items)
+	return ""
+}
+
+func TestStatusChange(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	srv.Send(
+		map[string]interface{}{
+			"type":     "presence_change",
+			"user":     "U1",
+			"presence": "away",
+		},
+	)
+
+	bob := entity(t, s, slk.TypeUser, "bob")
+	for i := 0; i < 100 && !bob.IsAway(); i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	srv.SetStatus("U1", ":palm_tree:", "on vacation")
+	var item string
+	for i := 0; i < 100 && !strings.HasSuffix(item, "on vacation"); i++ {
+		time.Sleep(time.Millisecond * 10)
+		item = bobListed(t, out, s)
+	}
+
+	if !strings.HasSuffix(item, ":palm_tree: on vacation") {
+		t.Errorf("status: %s", item)
+	}
+
+	if bob = entity(t, s, slk.TypeUser, "bob"); !bob.IsAway() {
+		t.Error("user_change reset the presence")
+	}
+
+	srv.SetStatusExpiration(
+		"U1",
+		":coffee:",
+		"brb",
+		time.Now().Add(time.Hour).Unix(),
+	)
+	for i := 0; i < 100 && !strings.HasSuffix(item, "brb"); i++ {
+		time.Sleep(time.Millisecond * 10)
+		item = bobListed(t, out, s)
+	}
+
+	if !strings.HasSuffix(item, ":coffee: brb") {
+		t.Errorf("status: %s", item)
+	}
+
+	srv.SetStatusExpiration(
+		"U1",
+		":tea:",
+		"later",
+		time.Now().Add(-time.Minute).Unix(),
+	)
+	for i := 0; i < 100 && strings.HasSuffix(item, "brb"); i++ {
+		time.Sleep(time.Millisecond * 10)
+		item = bobListed(t, out, s)
+	}
+
+	if item != "bob" {
+		t.Errorf("expired status shown: %s", item)
+	}
+}
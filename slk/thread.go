@@ -0,0 +1,116 @@
+package slk
+
+import (
+	"fmt"
+
+	"github.com/nlopes/slack"
+)
+
+// followed is the thread that is currently rendered and followed.
+type followed struct {
+	entity  Entity
+	channel string
+	ts      string
+	// items is the rendered thread, never modified so it can be shared.
+	items ListItems
+}
+
+// replies fetches all pages of the given thread, parent first.
+func (s *Slk) replies(channel, thread string) ([]slack.Message, error) {
+	msgs := make([]slack.Message, 0)
+	var cursor string
+	for {
+		page, next, err := s.c.GetConversationReplies(channel, thread, cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		msgs = append(msgs, page...)
+		if next == "" {
+			return msgs, nil
+		}
+
+		cursor = next
+	}
+}
+
+// threadItems fetches the given thread and converts it to a list.
+func (s *Slk) threadItems(e Entity, channel, thread string) (ListItems, error) {
+	msgs, err := s.replies(channel, thread)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(ListItems, 1, len(msgs)*2+1)
+	items[0] = &ListItem{
+		ListItemStatusTitle,
+		fmt.Sprintf("Thread in %s", e.QualifiedName()),
+	}
+
+	for i := range msgs {
+		items = append(items, s.replyItems(&msgs[i].Msg)...)
+	}
+
+	return items, nil
+}
+
+// replyItems converts a single message of a thread to list items.
+func (s *Slk) replyItems(m *slack.Msg) ListItems {
+	text, _ := s.msgText(m)
+	return ListItems{
+		&ListItem{
+			ListItemStatusNormal,
+			fmt.Sprintf(
+				"%s: %s",
+				s.msgUsername(m),
+				ts(m.Timestamp).Format(s.timeFormat),
+			),
+		},
+		&ListItem{ListItemStatusNone, text},
+	}
+}
+
+// follow sets the followed thread, nil stops following.
+func (s *Slk) follow(f *followed) {
+	s.mutex.Lock()
+	s.following = f
+	s.mutex.Unlock()
+}
+
+func (s *Slk) followedThread() *followed {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.following
+}
+
+// followReply appends m to the followed thread if it is a reply in it.
+func (s *Slk) followReply(m *slack.Msg) {
+	f := s.followedThread()
+	if f == nil || f.channel != m.Channel || f.ts != replyTo(m) {
+		return
+	}
+
+	reply := s.replyItems(m)
+	items := make(ListItems, 0, len(f.items)+len(reply))
+	items = append(items, f.items...)
+	items = append(items, reply...)
+
+	s.mutex.Lock()
+	if s.following != f {
+		// Closed or replaced in the meantime.
+		s.mutex.Unlock()
+		return
+	}
+
+	s.following = &followed{f.entity, f.channel, f.ts, items}
+	s.mutex.Unlock()
+
+	s.out.List(items, false)
+}
+
+// list writes items to the Output and stops following a thread since
+// the list will replace it.
+func (s *Slk) list(items ListItems, reverse bool) {
+	s.follow(nil)
+	s.out.List(items, reverse)
+}
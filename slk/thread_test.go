@@ -0,0 +1,111 @@
+package slk_test
+
+import (
+	"testing"
+
+	"github.com/frizinak/slek/slk"
+)
+
+func TestThreadFollow(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	s.Switch(c)
+	parent := srv.SendMessage("C1", "U1", "question?")
+	out.Wait("Msg", 1, wait)
+	srv.AddReply("C1", parent, "U1", "answer")
+
+	if err := s.Thread(c, parent); err != nil {
+		t.Fatal(err)
+	}
+
+	l := out.Wait("List", 1, wait)
+	if len(l) != 1 || len(l[0].Items) != 5 {
+		t.Fatalf("thread: %+v", out.Records(""))
+	}
+
+	if err := s.Reply(c, parent, "thanks"); err != nil {
+		t.Fatal(err)
+	}
+
+	l = out.Wait("List", 2, wait)
+	if len(l) != 2 || len(l[1].Items) != 7 {
+		t.Fatalf("followed: %+v", out.Records(""))
+	}
+
+	if text := l[1].Items[6].Value; text != "thanks" {
+		t.Errorf("reply: %s", text)
+	}
+
+	// Replies are appended without refetching the thread.
+	if n := len(srv.Calls("conversations.replies")); n != 1 {
+		t.Errorf("fetched the thread %d times", n)
+	}
+
+	s.CloseThread()
+	s.Reply(c, parent, "bye")
+	out.Wait("Msg", 3, wait)
+	if l = out.Records("List"); len(l) != 2 {
+		t.Errorf("closed thread rerendered: %+v", l)
+	}
+}
+
+func TestReply(t *testing.T) {
+	srv, out, s := start(t)
+	defer srv.Close()
+	defer s.Quit()
+
+	c := entity(t, s, slk.TypeChannel, "general")
+	s.Switch(c)
+	parent := srv.SendMessage("C1", "U1", "question?")
+	srv.SendMessage("C1", "U1", "other")
+	out.Wait("Msg", 2, wait)
+
+	ts, err := s.Recent(c, 2)
+	if err != nil || ts != parent {
+		t.Fatalf("recent: %s %v", ts, err)
+	}
+
+	if err := s.Reply(c, ts, "answer"); err != nil {
+		t.Fatal(err)
+	}
+
+	m := out.Wait("Msg", 3, wait)
+	if len(m) != 3 || m[2].Text != "answer" || m[2].Thread == nil {
+		t.Fatalf("reply: %+v", out.Records(""))
+	}
+
+	if m[2].Thread.From != "bob" || m[2].Thread.Text != "question?" {
+		t.Errorf("thread: %+v", m[2].Thread)
+	}
+
+	// Replying to a reply continues its thread.
+	ts, _ = s.Recent(c, 1)
+	if err := s.Reply(c, ts, "again"); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := srv.Calls("chat.postMessage")
+	if len(calls) != 2 || calls[1].Values.Get("thread_ts") != parent {
+		t.Errorf("chat.postMessage: %+v", calls)
+	}
+
+	// Replies to messages we have not seen still show their thread.
+	srv.Send(
+		map[string]interface{}{
+			"type":      "message",
+			"channel":   "C1",
+			"user":      "U1",
+			"text":      "late",
+			"ts":        srv.Ts(),
+			"thread_ts": "1.000001",
+		},
+	)
+
+	m = out.Wait("Msg", 5, wait)
+	if len(m) != 5 || m[4].Thread == nil || m[4].Thread.Text != "" {
+		t.Errorf("unknown parent: %+v", out.Records(""))
+	}
+}
@@ -0,0 +1,41 @@
+package slk
+
+import (
+	"fmt"
+	"time"
+)
+
+// typingInterval is the minimum amount of time between two typing events
+// for the same channel.
+const typingInterval = time.Second * 3
+
+func (s *Slk) typing(e Entity) error {
+	var id string
+	switch e.Type() {
+	case TypeUser:
+		// Don't open an im until we actually send something.
+		im := s.imByUser(e.ID())
+		if im == nilIM {
+			return fmt.Errorf("No im with %s", e.Name())
+		}
+
+		id = im.ID
+	case TypeChannel, TypeMPIM:
+		id = e.ID()
+	default:
+		return fmt.Errorf("Can not type in a %s", e.Type())
+	}
+
+	now := time.Now()
+	s.typingMutex.Lock()
+	if now.Sub(s.typingSent[id]) < typingInterval {
+		s.typingMutex.Unlock()
+		return nil
+	}
+
+	s.typingSent[id] = now
+	s.typingMutex.Unlock()
+
+	s.r.SendTyping(id)
+	return nil
+}
@@ -0,0 +1,319 @@
+package slk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+// webResponse contains the fields shared by all web api responses.
+type webResponse struct {
+	Ok       bool   `json:"ok"`
+	Error    string `json:"error"`
+	Metadata struct {
+		NextCursor string `json:"next_cursor"`
+	} `json:"response_metadata"`
+}
+
+func (r *webResponse) err() error {
+	if !r.Ok {
+		if r.Error == "" {
+			return errors.New("Unknown slack error")
+		}
+
+		return errors.New(r.Error)
+	}
+
+	return nil
+}
+
+type webAPIResponse interface {
+	err() error
+}
+
+// call performs a web api request for methods nlopes/slack does not
+// (fully) implement and decodes the response into r.
+func (s *slackAPI) call(
+	method string,
+	values url.Values,
+	r webAPIResponse,
+) error {
+	values.Set("token", s.token)
+	resp, err := slack.HTTPClient.PostForm(slack.SLACK_API+method, values)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Slack server error: %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+		return err
+	}
+
+	return r.err()
+}
+
+func (s *slackAPI) GetUsers() ([]User, error) {
+	r := &struct {
+		webResponse
+		Members []User `json:"members"`
+	}{}
+
+	err := s.call("users.list", url.Values{"presence": {"1"}}, r)
+	return r.Members, err
+}
+
+func (s *slackAPI) GetUserInfo(user string) (*User, error) {
+	r := &struct {
+		webResponse
+		User *User `json:"user"`
+	}{}
+
+	err := s.call("users.info", url.Values{"user": {user}}, r)
+	return r.User, err
+}
+
+func (s *slackAPI) GetUserGroupsWithUsers() ([]UserGroup, error) {
+	r := &struct {
+		webResponse
+		UserGroups []UserGroup `json:"usergroups"`
+	}{}
+
+	err := s.call(
+		"usergroups.list",
+		url.Values{"include_users": {"true"}},
+		r,
+	)
+
+	return r.UserGroups, err
+}
+
+func (s *slackAPI) SetUserCustomStatusWithExpiration(
+	statusText,
+	statusEmoji string,
+	statusExpiration int64,
+) error {
+	profile, err := json.Marshal(
+		map[string]interface{}{
+			"status_text":       statusText,
+			"status_emoji":      statusEmoji,
+			"status_expiration": statusExpiration,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.call(
+		"users.profile.set",
+		url.Values{"profile": {string(profile)}},
+		&webResponse{},
+	)
+}
+
+func (s *slackAPI) AddReminder(user, text, time string) (*Reminder, error) {
+	r := &struct {
+		webResponse
+		Reminder Reminder `json:"reminder"`
+	}{}
+
+	values := url.Values{"text": {text}, "time": {time}}
+	if user != "" {
+		values.Set("user", user)
+	}
+
+	err := s.call("reminders.add", values, r)
+	return &r.Reminder, err
+}
+
+func (s *slackAPI) ListReminders() ([]Reminder, error) {
+	r := &struct {
+		webResponse
+		Reminders []Reminder `json:"reminders"`
+	}{}
+
+	err := s.call("reminders.list", url.Values{}, r)
+	return r.Reminders, err
+}
+
+func (s *slackAPI) CompleteReminder(id string) error {
+	return s.call(
+		"reminders.complete",
+		url.Values{"reminder": {id}},
+		&webResponse{},
+	)
+}
+
+func (s *slackAPI) DeleteReminder(id string) error {
+	return s.call(
+		"reminders.delete",
+		url.Values{"reminder": {id}},
+		&webResponse{},
+	)
+}
+
+func (s *slackAPI) GetMPIMHistory(
+	channel string,
+	params slack.HistoryParameters,
+) (*slack.History, error) {
+	r := &struct {
+		webResponse
+		slack.History
+	}{}
+
+	values := url.Values{"channel": {channel}}
+	if params.Latest != slack.DEFAULT_HISTORY_LATEST {
+		values.Set("latest", params.Latest)
+	}
+
+	if params.Oldest != slack.DEFAULT_HISTORY_OLDEST {
+		values.Set("oldest", params.Oldest)
+	}
+
+	if params.Count != slack.DEFAULT_HISTORY_COUNT {
+		values.Set("count", strconv.Itoa(params.Count))
+	}
+
+	if params.Inclusive {
+		values.Set("inclusive", "1")
+	}
+
+	if params.Unreads {
+		values.Set("unreads", "1")
+	}
+
+	err := s.call("mpim.history", values, r)
+	return &r.History, err
+}
+
+func (s *slackAPI) MarkMPIM(channel, ts string) error {
+	return s.call(
+		"mpim.mark",
+		url.Values{"channel": {channel}, "ts": {ts}},
+		&webResponse{},
+	)
+}
+
+func (s *slackAPI) OpenMPIM(users []string) (*slack.Group, error) {
+	r := &struct {
+		webResponse
+		Group slack.Group `json:"group"`
+	}{}
+
+	err := s.call(
+		"mpim.open",
+		url.Values{"users": {strings.Join(users, ",")}},
+		r,
+	)
+
+	return &r.Group, err
+}
+
+func (s *slackAPI) CloseMPIM(channel string) error {
+	return s.call(
+		"mpim.close",
+		url.Values{"channel": {channel}},
+		&webResponse{},
+	)
+}
+
+func (s *slackAPI) UpdateMessage(
+	channel,
+	ts,
+	text string,
+) (string, string, string, error) {
+	r := &struct {
+		webResponse
+		Channel string `json:"channel"`
+		TS      string `json:"ts"`
+		Text    string `json:"text"`
+	}{}
+
+	err := s.call(
+		"chat.update",
+		url.Values{
+			"channel":    {channel},
+			"ts":         {ts},
+			"text":       {text},
+			"link_names": {"1"},
+		},
+		r,
+	)
+
+	return r.Channel, r.TS, r.Text, err
+}
+
+func (s *slackAPI) GetConversationMembers(
+	channel,
+	cursor string,
+) ([]string, string, error) {
+	r := &struct {
+		webResponse
+		Members []string `json:"members"`
+	}{}
+
+	values := url.Values{
+		"channel": {channel},
+		"limit":   {"500"},
+	}
+
+	if cursor != "" {
+		values.Set("cursor", cursor)
+	}
+
+	err := s.call("conversations.members", values, r)
+	return r.Members, r.Metadata.NextCursor, err
+}
+
+func (s *slackAPI) GetConversationReplies(
+	channel,
+	thread,
+	cursor string,
+) ([]slack.Message, string, error) {
+	r := &struct {
+		webResponse
+		Messages []slack.Message `json:"messages"`
+	}{}
+
+	values := url.Values{
+		"channel": {channel},
+		"ts":      {thread},
+		"limit":   {"200"},
+	}
+
+	if cursor != "" {
+		values.Set("cursor", cursor)
+	}
+
+	err := s.call("conversations.replies", values, r)
+	return r.Messages, r.Metadata.NextCursor, err
+}
+
+func (s *slackAPI) GetUserPrefs() (map[string]string, error) {
+	r := &struct {
+		webResponse
+		Prefs map[string]interface{} `json:"prefs"`
+	}{}
+
+	if err := s.call("users.prefs.get", url.Values{}, r); err != nil {
+		return nil, err
+	}
+
+	prefs := make(map[string]string, len(r.Prefs))
+	for name, value := range r.Prefs {
+		if str, ok := value.(string); ok {
+			prefs[name] = str
+		}
+	}
+
+	return prefs, nil
+}